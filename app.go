@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/gob"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
 )
 
 type app struct {
@@ -18,15 +20,27 @@ type app struct {
 	// MENU,PLAY,REPLAY
 	state    string
 	screen   tcell.Screen
+	store    storage
 	settings settings
 	defStyle tcell.Style
+	theme    theme
+	// built-in and user themes settings can choose from
+	themes []theme
+	// shown next to the field in play and replay until toggled off
+	minimapActive bool
+	// shown instead of the field in play and replay
+	overview overview
 
 	menu   menu
 	play   play
 	replay gameReplay
 
 	event chan tcell.Event
-	wg    sync.WaitGroup
+	// a bulk delete's undo time is over, events can be dropped but this can't
+	savedGamesDeleteDue chan struct{}
+	// a background recording is written or failed
+	recordingDone chan recordingResult
+	wg            sync.WaitGroup
 }
 
 type historyStep struct {
@@ -39,24 +53,36 @@ type historyStep struct {
 	OpenResult string
 }
 
-func createApp(s tcell.Screen, ctx context.Context, cancel context.CancelFunc, sett settings) app {
-	defStyle := tcell.StyleDefault.Background(tcell.ColorReset).Foreground(tcell.ColorReset)
-	switch sett.Theme {
-	case "LIGHT":
-		defStyle = tcell.StyleDefault.Background(tcell.Color254).Foreground(tcell.Color234)
-	case "DARK":
-		defStyle = tcell.StyleDefault.Background(tcell.Color234).Foreground(tcell.Color254)
-	}
-	s.SetStyle(defStyle)
+func createApp(s tcell.Screen, ctx context.Context, cancel context.CancelFunc, store storage, sett settings, themes []theme) app {
+	t := findTheme(themes, sett.Theme)
+	s.SetStyle(t.def)
 
 	return app{
 		ctx:      ctx,
 		cancel:   cancel,
 		state:    "MENU",
 		screen:   s,
+		store:    store,
 		event:    make(chan tcell.Event, 64),
 		settings: sett,
-		defStyle: defStyle,
+		defStyle: t.def,
+		theme:    t,
+		themes:   themes,
+
+		savedGamesDeleteDue: make(chan struct{}),
+		recordingDone:       make(chan recordingResult),
+	}
+}
+
+// the mouse is only used by the field, elsewhere the terminal keeps it to
+// select text like exported paths
+func (a *app) setState(state string) {
+	a.state = state
+
+	if state == "PLAY" || state == "REPLAY" {
+		a.screen.EnableMouse(tcell.MouseButtonEvents | tcell.MouseDragEvents)
+	} else {
+		a.screen.DisableMouse()
 	}
 }
 
@@ -94,7 +120,22 @@ func (a *app) eventReceiverLoop() {
 	for {
 		select {
 		case <-a.ctx.Done():
+			a.flushSavedGamesBulkDelete()
 			return
+		case <-a.savedGamesDeleteDue:
+			a.commitSavedGamesBulkDelete()
+			if a.savedGamesFindVisible() {
+				a.screen.Clear()
+				a.draw()
+				a.screen.Show()
+			}
+		case res := <-a.recordingDone:
+			a.finishReplayRecording(res)
+			if a.state == "REPLAY" && !a.replay.rInfo.autoplayActive {
+				a.screen.Clear()
+				a.draw()
+				a.screen.Show()
+			}
 		case ev := <-a.event:
 			done := make(chan struct{})
 
@@ -109,8 +150,17 @@ func (a *app) eventReceiverLoop() {
 						a.replay.rInfo.currScrollX, a.replay.rInfo.currScrollY = a.alignField(a.replay.rInfo.currX, a.replay.rInfo.currY, a.replay.rInfo.currScrollX, a.replay.rInfo.currScrollY)
 					}
 
-					a.menu.savedGamesFindCurr = 0
-					a.menu.savedGamesFindScreenOffset = 0
+					a.alignSavedGamesFind()
+					a.alignTrash()
+					a.alignDatabaseBackups()
+				case *tcell.EventMouse:
+					if a.state == "PLAY" {
+						a.eventMousePlay(ev)
+					}
+
+					if a.state == "REPLAY" {
+						a.eventMouseReplay(ev)
+					}
 				case *tcell.EventKey:
 					switch a.state {
 					case "MENU":
@@ -126,6 +176,9 @@ func (a *app) eventReceiverLoop() {
 
 			select {
 			case <-a.ctx.Done():
+				// the handler may still change the pending delete
+				<-done
+				a.flushSavedGamesBulkDelete()
 				return
 			case <-done:
 				switch ev.(type) {
@@ -133,6 +186,12 @@ func (a *app) eventReceiverLoop() {
 					a.screen.Clear()
 					a.draw()
 					a.screen.Sync()
+				case *tcell.EventInterrupt:
+					if a.state == "PLAY" || a.savedGamesFindVisible() {
+						a.screen.Clear()
+						a.draw()
+						a.screen.Show()
+					}
 				default:
 					if !a.replay.rInfo.autoplayActive {
 						a.screen.Clear()
@@ -148,18 +207,29 @@ func (a *app) eventReceiverLoop() {
 func (a *app) getFieldScreenSize() (xOffset, yOffset, width, height int) {
 	xOffset = 0
 	yOffset = 1
+	if a.state == "REPLAY" {
+		// header and timeline
+		yOffset = 2
+	}
 
 	terminalWidth, terminalHeight := a.screen.Size()
 
-	width = terminalWidth - xOffset
-	height = terminalHeight - yOffset
+	// in cells, which can be wider than a column and have grid lines between them
+	l := a.fieldLayout()
+	minimapWidth, _ := a.minimapSize(terminalWidth-xOffset, terminalHeight-yOffset)
+	if minimapWidth > 0 {
+		terminalWidth -= minimapWidth + 1
+	}
+	width = l.cellsFitting(terminalWidth-xOffset, l.cellWidth)
+	height = l.cellsFitting(terminalHeight-yOffset, 1)
 
 	return
 }
 
 func (a *app) setContentString(x, y int, style tcell.Style, content string) {
-	for i, r := range content {
-		a.screen.SetContent(x+i, y, r, nil, style)
+	for _, r := range content {
+		a.screen.SetContent(x, y, r, nil, style)
+		x += runewidth.RuneWidth(r)
 	}
 }
 
@@ -241,3 +311,40 @@ func fromGob[T any](b []byte) (T, error) {
 
 	return d, nil
 }
+
+// Every persisted value is wrapped in a record, so a change of its encoding
+// can be detected and migrated instead of silently decoding wrong.
+type record struct {
+	Version int
+	Payload []byte
+}
+
+// version of record payloads written by this build
+const RECORD_VERSION int = 1
+
+func toRecord[T any](d T) ([]byte, error) {
+	payload, err := toGob(d)
+	if err != nil {
+		return nil, err
+	}
+
+	return toGob(record{
+		Version: RECORD_VERSION,
+		Payload: payload,
+	})
+}
+
+func fromRecord[T any](b []byte) (T, error) {
+	var zero T
+
+	rec, err := fromGob[record](b)
+	if err != nil {
+		return zero, err
+	}
+
+	if rec.Version != RECORD_VERSION {
+		return zero, fmt.Errorf("record version is %d, expected %d", rec.Version, RECORD_VERSION)
+	}
+
+	return fromGob[T](rec.Payload)
+}
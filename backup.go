@@ -0,0 +1,360 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// startup backups kept in the backups directory, older ones are removed
+const AUTO_BACKUPS_KEPT int = 5
+
+// bolt.Compact commits after copying this many bytes
+const COMPACT_TX_MAX_SIZE int64 = 64 << 20
+
+const BACKUP_TIME_LAYOUT string = "20060102-150405"
+
+// Copies the database to backups/auto-<time>.db and removes all but the
+// newest AUTO_BACKUPS_KEPT of them. Runs on startup before migrations, a new
+// empty database isn't backed up.
+func (s *boltStorage) autoBackup() error {
+	var empty bool
+	err := s.view(func(tx *bolt.Tx) error {
+		empty = isEmpty(tx)
+		return nil
+	})
+	if err != nil || empty {
+		return err
+	}
+
+	backupsDir, err := getBackupsDir()
+	if err != nil {
+		return err
+	}
+
+	err = s.backupTo(newBackupPath(backupsDir, "auto"))
+	if err != nil {
+		return err
+	}
+
+	return rotateAutoBackups(backupsDir)
+}
+
+func rotateAutoBackups(backupsDir string) error {
+	entries, err := os.ReadDir(backupsDir)
+	if err != nil {
+		return err
+	}
+
+	// names sort by time, a numbered one can come before its unnumbered one
+	// of the same second, which is close enough
+	var autoBackups []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "auto-") && strings.HasSuffix(entry.Name(), ".db") {
+			autoBackups = append(autoBackups, entry.Name())
+		}
+	}
+	slices.Sort(autoBackups)
+
+	for len(autoBackups) > AUTO_BACKUPS_KEPT {
+		err := os.Remove(filepath.Join(backupsDir, autoBackups[0]))
+		if err != nil {
+			return err
+		}
+		autoBackups = autoBackups[1:]
+	}
+
+	return nil
+}
+
+// snapshot of the database in one read transaction, returns where it was written
+func (s *boltStorage) backup() (string, error) {
+	backupsDir, err := getBackupsDir()
+	if err != nil {
+		return "", err
+	}
+
+	path := newBackupPath(backupsDir, "data")
+	return path, s.backupTo(path)
+}
+
+// <kind>-<time>.db, numbered when a backup of the same second exists, which
+// would otherwise be overwritten, like the one being restored
+func newBackupPath(backupsDir, kind string) string {
+	name := kind + "-" + time.Now().Format(BACKUP_TIME_LAYOUT)
+	path := filepath.Join(backupsDir, name+".db")
+	for i := 2; ; i++ {
+		_, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return path
+		}
+		path = filepath.Join(backupsDir, name+"-"+strconv.Itoa(i)+".db")
+	}
+}
+
+// backups of this database, the newest first
+func listBackups() ([]string, error) {
+	backupsDir, err := getBackupsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(backupsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// names start with different kinds, so they are sorted by when they were written
+	modTimes := map[string]time.Time{}
+	var backups []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".db") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		path := filepath.Join(backupsDir, entry.Name())
+		modTimes[path] = info.ModTime()
+		backups = append(backups, path)
+	}
+
+	slices.SortStableFunc(backups, func(a, b string) int {
+		if c := modTimes[b].Compare(modTimes[a]); c != 0 {
+			return c
+		}
+		return strings.Compare(b, a)
+	})
+	return backups, nil
+}
+
+// Replaces the database with a copy of the file at path once it is known to be
+// a termines database this version can read. The current database is backed
+// up first, so a restore can be undone by restoring that backup.
+func (s *boltStorage) restore(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if absPath == s.path {
+		return "", fmt.Errorf("%s is the database in use", path)
+	}
+
+	err = validateDatabase(path)
+	if err != nil {
+		return "", fmt.Errorf("%s can't be restored: %w", path, err)
+	}
+
+	backupPath, err := s.backup()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.replaceFile(func(tmpPath string) error {
+		return copyFile(path, tmpPath)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return backupPath, s.migrate()
+}
+
+// Rewrites the database into a fresh file, which leaves out the free pages
+// bbolt never gives back. Returns sizes in bytes before and after.
+func (s *boltStorage) compact() (int64, int64, error) {
+	before, err := fileSize(s.path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	err = s.replaceFile(func(tmpPath string) error {
+		dst, err := bolt.Open(tmpPath, 0600, &bolt.Options{Timeout: time.Second})
+		if err != nil {
+			return err
+		}
+
+		err = bolt.Compact(dst, s.db, COMPACT_TX_MAX_SIZE)
+		if err != nil {
+			dst.Close()
+			return err
+		}
+
+		return dst.Close()
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	after, err := fileSize(s.path)
+	return before, after, err
+}
+
+// Write fills a temporary file next to the database, which then takes its
+// place while the database is closed. Every other use of the database waits
+// until it is open again, on the old file when the new one doesn't open.
+func (s *boltStorage) replaceFile(write func(tmpPath string) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return errDatabaseClosed
+	}
+
+	tmpPath := s.path + ".tmp"
+	err := os.Remove(tmpPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	err = write(tmpPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	err = s.db.Close()
+	if err == nil {
+		err = s.swapFile(tmpPath)
+		if err == nil {
+			return nil
+		}
+	}
+	os.Remove(tmpPath)
+
+	// a missing file would open as a new empty database
+	_, statErr := os.Stat(s.path)
+	if statErr != nil {
+		s.db = nil
+		return fmt.Errorf("%w: %w", errDatabaseClosed, err)
+	}
+
+	db, reopenErr := bolt.Open(s.path, 0600, &bolt.Options{Timeout: time.Second})
+	if reopenErr != nil {
+		s.db = nil
+		return fmt.Errorf("%w: %w", errDatabaseClosed, reopenErr)
+	}
+	s.db = db
+
+	return err
+}
+
+// moves the file at tmpPath to the closed database's path and opens it, the
+// replaced file is moved back when any of it fails
+func (s *boltStorage) swapFile(tmpPath string) error {
+	oldPath := s.path + ".old"
+	err := os.Rename(s.path, oldPath)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, s.path)
+	if err == nil {
+		var db *bolt.DB
+		db, err = bolt.Open(s.path, 0600, &bolt.Options{Timeout: time.Second})
+		if err == nil {
+			s.db = db
+			return os.Remove(oldPath)
+		}
+	}
+
+	renameErr := os.Rename(oldPath, s.path)
+	if renameErr != nil {
+		return fmt.Errorf("%w, the replaced database is at %s", renameErr, oldPath)
+	}
+
+	return err
+}
+
+// a termines database of a schema version this build can migrate, with
+// consistent pages and readable games
+func validateDatabase(path string) error {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		// Check stops only once every error it found is received
+		var checkErr error
+		for err := range tx.Check() {
+			if checkErr == nil {
+				checkErr = err
+			}
+		}
+		if checkErr != nil {
+			return checkErr
+		}
+
+		if tx.Bucket([]byte("Meta")) == nil && tx.Bucket([]byte("Settings")) == nil && tx.Bucket([]byte("GameInfo")) == nil {
+			return fmt.Errorf("not a termines database")
+		}
+
+		version := getSchemaVersion(tx)
+		if version > latestSchemaVersion() {
+			return fmt.Errorf("database schema version %d is newer than this termines supports (%d)", version, latestSchemaVersion())
+		}
+
+		// version 0 records are checked by the migrations
+		if version == 0 {
+			return nil
+		}
+
+		bucketGameInfo := tx.Bucket([]byte("GameInfo"))
+		if bucketGameInfo == nil {
+			return nil
+		}
+
+		return bucketGameInfo.ForEach(func(_, v []byte) error {
+			_, err := fromRecord[gameInfo](v)
+			return err
+		})
+	})
+}
+
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
+
+func isEmpty(tx *bolt.Tx) bool {
+	return tx.ForEach(func(_ []byte, _ *bolt.Bucket) error {
+		return fmt.Errorf("not empty")
+	}) == nil
+}
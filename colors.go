@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// colours Screen.Colors reports for terminals with 24-bit colour
+const TRUECOLOR_COLORS int = 1 << 24
+
+var settingsColorModes = []string{"AUTO", "TRUECOLOR", "256", "16", "8"}
+
+// number of colours the screen can show, the ColorMode setting overrides
+// what the terminal reports
+func colorModeColors(colorMode string, screen tcell.Screen) int {
+	switch colorMode {
+	case "TRUECOLOR":
+		return TRUECOLOR_COLORS
+	case "256":
+		return 256
+	case "16":
+		return 16
+	case "8":
+		return 8
+	}
+
+	return screen.Colors()
+}
+
+func (a *app) colors() int {
+	return colorModeColors(a.settings.ColorMode, a.screen)
+}
+
+// Fits a theme colour to what the terminal shows. Truecolor terminals get
+// exact RGB, also for palette colours whose look would otherwise depend on
+// the terminal, and the rest get the closest colour of their palette. On 8
+// colour terminals bright colours are their dark half shown bold, which is
+// how the Linux console draws them, so bold is returned for foregrounds.
+func fitColor(c tcell.Color, colors int) (tcell.Color, bool) {
+	if !c.Valid() || c == tcell.ColorReset {
+		return c, false
+	}
+
+	switch {
+	case colors >= TRUECOLOR_COLORS:
+		return c.TrueColor(), false
+	case colors >= 256:
+		if c.IsRGB() {
+			return tcell.FindColor(c, paletteColors(256)), false
+		}
+		return c, false
+	case colors >= 16:
+		return fitPaletteColor(c, 16), false
+	case colors >= 8:
+		c = fitPaletteColor(c, 16)
+		if c >= tcell.PaletteColor(8) {
+			return c - 8, true
+		}
+		return c, false
+	}
+
+	// monochrome
+	return tcell.ColorReset, false
+}
+
+func fitPaletteColor(c tcell.Color, n int) tcell.Color {
+	if !c.IsRGB() && c < tcell.PaletteColor(n) {
+		return c
+	}
+
+	return tcell.FindColor(c, paletteColors(n))
+}
+
+func paletteColors(n int) []tcell.Color {
+	palette := make([]tcell.Color, n)
+	for i := range palette {
+		palette[i] = tcell.PaletteColor(i)
+	}
+
+	return palette
+}
+
+func colorModeStr(colorMode string, colors int) string {
+	switch colorMode {
+	case "TRUECOLOR":
+		return "Truecolor"
+	case "256", "16", "8":
+		return colorMode
+	}
+
+	switch {
+	case colors >= TRUECOLOR_COLORS:
+		return "Auto (Truecolor)"
+	case colors <= 1:
+		return "Auto (Mono)"
+	}
+	return "Auto (" + strconv.Itoa(colors) + ")"
+}
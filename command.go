@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+const COMMAND_USAGE string = `usage: termines [command]
+
+Without a command termines starts the game.
+
+commands:
+  backup          copy the database to the backups directory
+  restore <file>  replace the database with a backup, backing it up first
+  compact         rewrite the database into a smaller file
+  import <file>   add saved games of another termines database`
+
+// returned for an unknown command or wrong arguments, main prints the usage
+var errUsage = errors.New("unknown command")
+
+// Database maintenance run instead of the game, the database is open but not
+// migrated yet.
+func runCommand(store *boltStorage, args []string) error {
+	switch {
+	case len(args) == 1 && args[0] == "backup":
+		path, err := store.backup()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Backed up to " + path)
+	case len(args) == 2 && args[0] == "restore":
+		backupPath, err := store.restore(args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Restored " + args[1] + ", the replaced database is at " + backupPath)
+	case len(args) == 1 && args[0] == "compact":
+		before, after, err := store.compact()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Compacted from " + sizeStr(before) + " to " + sizeStr(after))
+	case len(args) == 2 && args[0] == "import":
+		err := store.migrate()
+		if err != nil {
+			return err
+		}
+
+		report, err := store.importGames(args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(importReportStr(report))
+	default:
+		return errUsage
+	}
+
+	return nil
+}
+
+func importReportStr(report importReport) string {
+	return fmt.Sprintf("Imported %d games, skipped %d already saved, %d invalid", report.Imported, report.Skipped, report.Invalid)
+}
+
+func sizeStr(bytes int64) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(bytes)/(1<<10))
+	}
+
+	return fmt.Sprintf("%d B", bytes)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const EXPORT_CELL_WIDTH int = 12
+const EXPORT_CELL_HEIGHT int = 16
+const EXPORT_GLYPH_SCALE int = 2
+
+// 5x7 bitmaps, one string per row, '#' is a set pixel
+var exportFont = map[rune][7]string{
+	'0': {" ### ", "#   #", "#  ##", "# # #", "##  #", "#   #", " ### "},
+	'1': {"  #  ", " ##  ", "  #  ", "  #  ", "  #  ", "  #  ", " ### "},
+	'2': {" ### ", "#   #", "    #", "   # ", "  #  ", " #   ", "#####"},
+	'3': {"#####", "   # ", "  #  ", "   # ", "    #", "#   #", " ### "},
+	'4': {"   # ", "  ## ", " # # ", "#  # ", "#####", "   # ", "   # "},
+	'5': {"#####", "#    ", "#### ", "    #", "    #", "#   #", " ### "},
+	'6': {"  ## ", " #   ", "#    ", "#### ", "#   #", "#   #", " ### "},
+	'7': {"#####", "    #", "   # ", "  #  ", " #   ", " #   ", " #   "},
+	'8': {" ### ", "#   #", "#   #", " ### ", "#   #", "#   #", " ### "},
+	'9': {" ### ", "#   #", "#   #", " ####", "    #", "   # ", " ##  "},
+	'-': {"     ", "     ", "     ", "#####", "     ", "     ", "     "},
+	'F': {"#####", "#    ", "#    ", "#### ", "#    ", "#    ", "#    "},
+	'M': {"#   #", "## ##", "# # #", "# # #", "#   #", "#   #", "#   #"},
+}
+
+func (a *app) exportReplayStep() (string, error) {
+	exportsDir, err := getExportsDir()
+	if err != nil {
+		return "", err
+	}
+
+	name := a.replay.gInfo.CreatedAt.Format("2006-01-02_15-04-05") + "_step" + strconv.Itoa(a.replay.rInfo.currStepIdx+1)
+	basePath := filepath.Join(exportsDir, name)
+
+	field := a.replay.gData.Field
+	cursorX := a.replay.rInfo.currX
+	cursorY := a.replay.rInfo.currY
+
+	err = a.exportFieldPNG(basePath+".png", field, cursorX, cursorY)
+	if err != nil {
+		return "", err
+	}
+
+	err = a.exportFieldSVG(basePath+".svg", field, cursorX, cursorY)
+	if err != nil {
+		return "", err
+	}
+
+	return basePath, nil
+}
+
+func (a *app) exportFieldPNG(path string, field [][]fieldCell, cursorX, cursorY int) error {
+	img := a.renderFieldImage(field, cursorX, cursorY)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return png.Encode(file, img)
+}
+
+func (a *app) renderFieldImage(field [][]fieldCell, cursorX, cursorY int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, len(field[0])*EXPORT_CELL_WIDTH, len(field)*EXPORT_CELL_HEIGHT))
+
+	for y := range field {
+		for x := range field[y] {
+			a.drawFieldImageCell(img, field, x, y, cursorX, cursorY)
+		}
+	}
+
+	return img
+}
+
+// draws the cell at x, y over what img had there, returns the pixels it covers
+func (a *app) drawFieldImageCell(img *image.RGBA, field [][]fieldCell, x, y, cursorX, cursorY int) image.Rectangle {
+	// the bitmap font only has the ASCII glyphs
+	_, style := a.cellToStyle(field[y][x])
+	rune := findGlyphSet("ASCII").cellRune(field[y][x])
+	if x == cursorX && y == cursorY {
+		style = a.theme.cursorStyle(style)
+	}
+	fg, bg := a.styleToRGBA(style)
+
+	cellX := x * EXPORT_CELL_WIDTH
+	cellY := y * EXPORT_CELL_HEIGHT
+	for py := range EXPORT_CELL_HEIGHT {
+		for px := range EXPORT_CELL_WIDTH {
+			img.SetRGBA(cellX+px, cellY+py, bg)
+		}
+	}
+
+	drawExportGlyph(img, cellX, cellY, rune, fg)
+
+	return image.Rect(cellX, cellY, cellX+EXPORT_CELL_WIDTH, cellY+EXPORT_CELL_HEIGHT)
+}
+
+func drawExportGlyph(img *image.RGBA, cellX, cellY int, r rune, fg color.RGBA) {
+	if r == ' ' {
+		return
+	}
+
+	glyphX := cellX + (EXPORT_CELL_WIDTH-5*EXPORT_GLYPH_SCALE)/2
+	glyphY := cellY + (EXPORT_CELL_HEIGHT-7*EXPORT_GLYPH_SCALE)/2
+
+	bitmap, ok := exportFont[r]
+	if !ok {
+		// unknown glyph, mark the cell with a small square instead
+		for py := 2 * EXPORT_GLYPH_SCALE; py < 5*EXPORT_GLYPH_SCALE; py++ {
+			for px := EXPORT_GLYPH_SCALE; px < 4*EXPORT_GLYPH_SCALE; px++ {
+				img.SetRGBA(glyphX+px, glyphY+py, fg)
+			}
+		}
+		return
+	}
+
+	for row, line := range bitmap {
+		for col, pixel := range line {
+			if pixel != '#' {
+				continue
+			}
+			for sy := range EXPORT_GLYPH_SCALE {
+				for sx := range EXPORT_GLYPH_SCALE {
+					img.SetRGBA(glyphX+col*EXPORT_GLYPH_SCALE+sx, glyphY+row*EXPORT_GLYPH_SCALE+sy, fg)
+				}
+			}
+		}
+	}
+}
+
+func (a *app) exportFieldSVG(path string, field [][]fieldCell, cursorX, cursorY int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+
+	width := len(field[0]) * EXPORT_CELL_WIDTH
+	height := len(field) * EXPORT_CELL_HEIGHT
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", width, height, width, height)
+	fmt.Fprintf(w, "<g font-family=\"monospace\" font-size=\"%d\" text-anchor=\"middle\" dominant-baseline=\"central\">\n", EXPORT_CELL_HEIGHT-2)
+
+	for y := range field {
+		for x := range field[y] {
+			rune, style := a.cellToStyle(field[y][x])
+			if x == cursorX && y == cursorY {
+				style = a.theme.cursorStyle(style)
+			}
+			fg, bg := a.styleToRGBA(style)
+
+			cellX := x * EXPORT_CELL_WIDTH
+			cellY := y * EXPORT_CELL_HEIGHT
+			fmt.Fprintf(w, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>", cellX, cellY, EXPORT_CELL_WIDTH, EXPORT_CELL_HEIGHT, rgbaToHex(bg))
+			if rune != ' ' {
+				fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\" fill=\"%s\">%s</text>", cellX+EXPORT_CELL_WIDTH/2, cellY+EXPORT_CELL_HEIGHT/2, rgbaToHex(fg), svgEscape(rune))
+			}
+			fmt.Fprint(w, "\n")
+		}
+	}
+
+	fmt.Fprint(w, "</g>\n</svg>\n")
+
+	return w.Flush()
+}
+
+// terminal default colours have no RGB value, so they are replaced by
+// the colours the theme is usually seen with
+func (a *app) styleToRGBA(style tcell.Style) (fg color.RGBA, bg color.RGBA) {
+	fgColor, bgColor, attrs := style.Decompose()
+
+	fg = tcellToRGBA(fgColor, color.RGBA{R: 0xe4, G: 0xe4, B: 0xe4, A: 0xff})
+	bg = tcellToRGBA(bgColor, color.RGBA{R: 0x1c, G: 0x1c, B: 0x1c, A: 0xff})
+
+	if attrs&tcell.AttrReverse != 0 {
+		fg, bg = bg, fg
+	}
+
+	return fg, bg
+}
+
+func tcellToRGBA(c tcell.Color, fallback color.RGBA) color.RGBA {
+	r, g, b := c.RGB()
+	if r < 0 || g < 0 || b < 0 {
+		return fallback
+	}
+
+	return color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 0xff}
+}
+
+func rgbaToHex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func svgEscape(r rune) string {
+	switch r {
+	case '<':
+		return "&lt;"
+	case '>':
+		return "&gt;"
+	case '&':
+		return "&amp;"
+	}
+
+	return string(r)
+}
+
+func getExportsDir() (string, error) {
+	terminesDir, err := getTerminesDir()
+	if err != nil {
+		return "", err
+	}
+
+	exportsDir := filepath.Join(terminesDir, "exports")
+
+	err = os.MkdirAll(exportsDir, 0o755)
+	if err != nil {
+		return "", err
+	}
+
+	return exportsDir, nil
+}
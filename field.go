@@ -4,6 +4,7 @@ import (
 	"math/rand/v2"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
 )
 
 const CELL_STATE_OPEN int = 1
@@ -19,7 +20,10 @@ type fieldCell struct {
 	State int
 }
 
-func createField(width, height, mineCount int) [][]fieldCell {
+// the same seed places mines the same way on a field of the same size
+func createField(width, height, mineCount int, seed uint64) [][]fieldCell {
+	r := rand.New(rand.NewPCG(seed, seed))
+
 	field := make([][]fieldCell, height)
 	for i := range height {
 		field[i] = make([]fieldCell, width)
@@ -27,8 +31,8 @@ func createField(width, height, mineCount int) [][]fieldCell {
 
 	emptySpaces := len(field) * len(field[0])
 	for mineCount > 0 && emptySpaces > 0 {
-		y := rand.IntN(len(field))
-		x := rand.IntN(len(field[0]))
+		y := r.IntN(len(field))
+		x := r.IntN(len(field[0]))
 
 		if field[y][x].Value == CELL_VALUE_MINE {
 			continue
@@ -66,6 +70,7 @@ func createField(width, height, mineCount int) [][]fieldCell {
 
 func (a *app) drawField(cursorX, cursorY, scrollX, scrollY int, field [][]fieldCell) {
 	xOffset, yOffset, fieldScreenWidth, fieldScreenHeight := a.getFieldScreenSize()
+	l := a.fieldLayout()
 	for fieldScreenY := range fieldScreenHeight {
 		fieldY := fieldScreenY + scrollY
 		if fieldY >= len(field) {
@@ -78,16 +83,27 @@ func (a *app) drawField(cursorX, cursorY, scrollX, scrollY int, field [][]fieldC
 			}
 
 			rune, style := a.cellToStyle(field[fieldY][fieldX])
-			a.screen.SetContent(fieldScreenX+xOffset, fieldScreenY+yOffset, rune, nil, style)
-
+			a.setCellContent(xOffset+l.cellOffset(scrollX, fieldScreenX, l.cellWidth), yOffset+l.cellOffset(scrollY, fieldScreenY, 1), l.cellWidth, rune, style)
 		}
 	}
 
-	screenX := cursorX - scrollX + xOffset
-	screenY := cursorY - scrollY + yOffset
-	if screenX >= xOffset && screenY >= yOffset && screenX < fieldScreenWidth+xOffset && screenY < fieldScreenHeight+yOffset {
+	visibleWidth := min(fieldScreenWidth, len(field[0])-scrollX)
+	visibleHeight := min(fieldScreenHeight, len(field)-scrollY)
+	a.drawFieldGrid(l, xOffset, yOffset, scrollX, scrollY, visibleWidth, visibleHeight, a.defStyle)
+
+	screenX := cursorX - scrollX
+	screenY := cursorY - scrollY
+	if screenX >= 0 && screenY >= 0 && screenX < fieldScreenWidth && screenY < fieldScreenHeight {
 		rune, style := a.cellToStyle(field[cursorY][cursorX])
-		a.screen.SetContent(screenX, screenY, rune, nil, style.Reverse(true))
+		a.setCellContent(xOffset+l.cellOffset(scrollX, screenX, l.cellWidth), yOffset+l.cellOffset(scrollY, screenY, 1), l.cellWidth, rune, a.theme.cursorStyle(style))
+	}
+}
+
+// glyphs narrower than the cell are padded with the cell's style
+func (a *app) setCellContent(x, y, cellWidth int, rune rune, style tcell.Style) {
+	a.screen.SetContent(x, y, rune, nil, style)
+	for i := runewidth.RuneWidth(rune); i < cellWidth; i++ {
+		a.screen.SetContent(x+i, y, ' ', nil, style)
 	}
 }
 
@@ -497,55 +513,9 @@ func moveBigLeft(field [][]fieldCell, x, y int) int {
 }
 
 func (a *app) cellToStyle(cell fieldCell) (rune, tcell.Style) {
-	switch cell.State {
-	case CELL_STATE_HIDDEN:
-		return '-', a.defStyle
-	case CELL_STATE_FLAG:
-		if a.settings.Theme == "MONO" {
-			return 'F', a.defStyle
-		} else {
-			return 'F', a.defStyle.Background(tcell.ColorRed)
-		}
-	}
-
-	style := a.defStyle
-	rune := ' '
-
-	switch cell.Value {
-	case 0:
-		style = a.defStyle
-		rune = '0'
-	case 1:
-		style = a.defStyle.Foreground(tcell.Color27)
-		rune = '1'
-	case 2:
-		style = a.defStyle.Foreground(tcell.Color34)
-		rune = '2'
-	case 3:
-		style = a.defStyle.Foreground(tcell.ColorRed)
-		rune = '3'
-	case 4:
-		style = a.defStyle.Foreground(tcell.Color205)
-		rune = '4'
-	case 5:
-		style = a.defStyle.Foreground(tcell.Color124)
-		rune = '5'
-	case 6:
-		style = a.defStyle.Foreground(tcell.Color45)
-		rune = '6'
-	case 7:
-		style = a.defStyle.Foreground(tcell.Color92)
-		rune = '7'
-	case 8:
-		style = a.defStyle.Foreground(tcell.ColorGray)
-		rune = '8'
-	case 9:
-		style = a.defStyle.Background(tcell.ColorRed)
-		rune = 'M'
-	}
-
-	if a.settings.Theme == "MONO" {
-		style = a.defStyle
+	rune, style := a.glyphSet().cellRune(cell), a.theme.cellStyle(cell)
+	if a.settings.DistinctCells {
+		style = distinctCellStyle(cell, style)
 	}
 
 	return rune, style
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"github.com/mattn/go-runewidth"
+)
+
+// runes of the cells that aren't numbers, numbers are always their digit
+type glyphSet struct {
+	Hidden rune
+	Flag   rune
+	Mine   rune
+	// opened cell without mines around
+	Zero rune
+}
+
+// in the order settings shows them
+var settingsGlyphSets = []string{"ASCII", "UNICODE", "NERD"}
+
+var glyphSets = map[string]glyphSet{
+	"ASCII": {
+		Hidden: '-',
+		Flag:   'F',
+		Mine:   'M',
+		Zero:   '0',
+	},
+	"UNICODE": {
+		Hidden: '▒',
+		Flag:   '⚑',
+		Mine:   '✹',
+		Zero:   '·',
+	},
+	// needs a Nerd Font, https://www.nerdfonts.com
+	"NERD": {
+		Hidden: '\uf0c8', // nf-fa-square
+		Flag:   '\uf024', // nf-fa-flag
+		Mine:   '\uf1e2', // nf-fa-bomb
+		Zero:   '·',
+	},
+}
+
+// ASCII when name isn't a glyph set
+func findGlyphSet(name string) glyphSet {
+	if g, ok := glyphSets[name]; ok {
+		return g
+	}
+
+	return glyphSets["ASCII"]
+}
+
+func (g glyphSet) cellRune(cell fieldCell) rune {
+	switch cell.State {
+	case CELL_STATE_HIDDEN:
+		return g.Hidden
+	case CELL_STATE_FLAG:
+		return g.Flag
+	}
+
+	switch cell.Value {
+	case 0:
+		return g.Zero
+	case CELL_VALUE_MINE:
+		return g.Mine
+	}
+
+	return rune('0' + cell.Value)
+}
+
+// Columns every cell takes, so the field stays aligned when a glyph is
+// double width, which for some glyphs depends on the terminal's locale.
+func (g glyphSet) cellWidth() int {
+	width := 1
+	for _, r := range []rune{g.Hidden, g.Flag, g.Mine, g.Zero} {
+		width = max(width, runewidth.RuneWidth(r))
+	}
+
+	return width
+}
+
+func (a *app) glyphSet() glyphSet {
+	return findGlyphSet(a.settings.Glyphs)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type importReport struct {
+	Imported int
+	// already in this database or its trash
+	Skipped int
+	// not replayable to the result they claim
+	Invalid int
+}
+
+// Merges the saved games of another termines database into this one. The
+// other database is migrated on a temporary copy so the file itself is only
+// read, then every game not known here by id is replayed before it is added.
+func (s *boltStorage) importGames(path string) (importReport, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return importReport{}, err
+	}
+	if absPath == s.path {
+		return importReport{}, fmt.Errorf("%s is the database in use", path)
+	}
+
+	err = validateDatabase(path)
+	if err != nil {
+		return importReport{}, fmt.Errorf("%s can't be imported: %w", path, err)
+	}
+
+	tmpFile, err := os.CreateTemp("", "termines-import-*.db")
+	if err != nil {
+		return importReport{}, err
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+	defer os.Remove(tmpPath)
+
+	err = copyFile(path, tmpPath)
+	if err != nil {
+		return importReport{}, err
+	}
+
+	other, err := openBoltStorage(tmpPath)
+	if err != nil {
+		return importReport{}, err
+	}
+	defer other.close()
+
+	var version int
+	err = other.view(func(tx *bolt.Tx) error {
+		version = getSchemaVersion(tx)
+		return nil
+	})
+	if err != nil {
+		return importReport{}, err
+	}
+
+	err = other.runMigrations(version)
+	if err != nil {
+		return importReport{}, err
+	}
+
+	var report importReport
+	err = other.view(func(otherTx *bolt.Tx) error {
+		otherGameInfo := otherTx.Bucket([]byte("GameInfo"))
+		otherGameData := otherTx.Bucket([]byte("GameData"))
+		if otherGameInfo == nil {
+			return nil
+		}
+
+		return s.update(func(tx *bolt.Tx) error {
+			buckets, err := createBuckets(tx, "GameInfo", "GameData", "Trash")
+			if err != nil {
+				return err
+			}
+			bucketGameInfo, bucketGameData, bucketTrash := buckets[0], buckets[1], buckets[2]
+
+			return otherGameInfo.ForEach(func(k, v []byte) error {
+				if bucketGameInfo.Get(k) != nil || bucketTrash.Get(k) != nil {
+					report.Skipped++
+					return nil
+				}
+
+				gInfo, err := fromRecord[gameInfo](v)
+				if err != nil {
+					report.Invalid++
+					return nil
+				}
+
+				var value []byte
+				if otherGameData != nil {
+					value = otherGameData.Get(k)
+				}
+				if value == nil {
+					report.Invalid++
+					return nil
+				}
+
+				gData, err := fromRecord[gameData](value)
+				if err != nil || validateGame(gInfo, gData) != nil {
+					report.Invalid++
+					return nil
+				}
+
+				err = putGameIndexes(tx, gInfo)
+				if err != nil {
+					return err
+				}
+
+				gobGameInfo, err := toRecord(gInfo)
+				if err != nil {
+					return err
+				}
+
+				err = bucketGameInfo.Put(k, gobGameInfo)
+				if err != nil {
+					return err
+				}
+
+				gobGameData, err := toRecord(gData)
+				if err != nil {
+					return err
+				}
+
+				err = bucketGameData.Put(k, gobGameData)
+				if err != nil {
+					return err
+				}
+
+				report.Imported++
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return importReport{}, err
+	}
+
+	return report, nil
+}
+
+// Replays the game through nextStep on a closed copy of its field, which has
+// to end with the result, size and mines its info claims.
+func validateGame(gInfo gameInfo, gData gameData) error {
+	if gInfo.Id != gData.Id {
+		return fmt.Errorf("info and data ids differ")
+	}
+
+	height := len(gData.Field)
+	if height == 0 || height != gInfo.FieldHeight {
+		return fmt.Errorf("field height isn't %d", gInfo.FieldHeight)
+	}
+
+	mineCount := 0
+	for y := range gData.Field {
+		if len(gData.Field[y]) != gInfo.FieldWidth {
+			return fmt.Errorf("field width isn't %d", gInfo.FieldWidth)
+		}
+
+		for x := range gData.Field[y] {
+			if gData.Field[y][x].Value == CELL_VALUE_MINE {
+				mineCount++
+			} else if gData.Field[y][x].Value != fieldMinesAround(gData.Field, x, y) {
+				return fmt.Errorf("wrong number at %d,%d", x, y)
+			}
+		}
+	}
+	if mineCount != gInfo.MineCount {
+		return fmt.Errorf("field has %d mines instead of %d", mineCount, gInfo.MineCount)
+	}
+
+	if len(gData.History) == 0 {
+		return fmt.Errorf("history is empty")
+	}
+
+	field := closeFieldCopy(gData.Field)
+	result := "NONE"
+	prevDuration := time.Duration(0)
+	var x, y int
+	for i, step := range gData.History {
+		if result != "NONE" {
+			return fmt.Errorf("step %d is after the game ended", i)
+		}
+		if step.Kind != "MOVE" && step.Kind != "OPEN" && step.Kind != "FLAG" {
+			return fmt.Errorf("step %d is of unknown kind %s", i, step.Kind)
+		}
+		if step.CurrGameDuration < prevDuration {
+			return fmt.Errorf("step %d goes back in time", i)
+		}
+		prevDuration = step.CurrGameDuration
+
+		var err error
+		x, y, err = nextStep(field, x, y, step)
+		if err != nil {
+			return fmt.Errorf("step %d: %w", i, err)
+		}
+		if x < 0 || x >= gInfo.FieldWidth || y < 0 || y >= gInfo.FieldHeight {
+			return fmt.Errorf("step %d moves outside the field", i)
+		}
+
+		if step.Kind == "OPEN" {
+			result = step.OpenResult
+		}
+	}
+
+	if result != gInfo.Result {
+		return fmt.Errorf("game ended %s instead of %s", result, gInfo.Result)
+	}
+	if prevDuration != gInfo.GameDuration {
+		return fmt.Errorf("game took %s instead of %s", prevDuration, gInfo.GameDuration)
+	}
+
+	return nil
+}
+
+func fieldMinesAround(field [][]fieldCell, x, y int) int {
+	mineCount := 0
+	for cy := max(y-1, 0); cy <= min(y+1, len(field)-1); cy++ {
+		for cx := max(x-1, 0); cx <= min(x+1, len(field[cy])-1); cx++ {
+			if field[cy][cx].Value == CELL_VALUE_MINE {
+				mineCount++
+			}
+		}
+	}
+
+	return mineCount
+}
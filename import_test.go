@@ -0,0 +1,95 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the version 0 fixture game is a valid won game, each case breaks one thing about it
+func TestValidateGame(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(gInfo *gameInfo, gData *gameData)
+		// in the error, empty when the game is valid
+		want string
+	}{
+		{
+			name:   "valid",
+			change: func(gInfo *gameInfo, gData *gameData) {},
+		},
+		{
+			name: "wrong number",
+			change: func(gInfo *gameInfo, gData *gameData) {
+				gData.Field[1][0].Value = 2
+			},
+			want: "wrong number at 0,1",
+		},
+		{
+			name: "mine count mismatch",
+			change: func(gInfo *gameInfo, gData *gameData) {
+				gInfo.MineCount = 2
+			},
+			want: "1 mines instead of 2",
+		},
+		{
+			name: "step after the end",
+			change: func(gInfo *gameInfo, gData *gameData) {
+				gData.History = append(gData.History, historyStep{CurrGameDuration: 3 * time.Second, Kind: "FLAG"})
+			},
+			want: "step 3 is after the game ended",
+		},
+		{
+			name: "move outside the field",
+			change: func(gInfo *gameInfo, gData *gameData) {
+				gData.History[1].MoveX = 3
+			},
+			want: "step 1 moves outside the field",
+		},
+		{
+			name: "wrong open result",
+			change: func(gInfo *gameInfo, gData *gameData) {
+				gData.History[0].OpenResult = "LOST"
+			},
+			want: "step 0:",
+		},
+		{
+			name: "result differs from info",
+			change: func(gInfo *gameInfo, gData *gameData) {
+				gInfo.Result = "LOST"
+			},
+			want: "game ended WON instead of LOST",
+		},
+		{
+			name: "duration differs from info",
+			change: func(gInfo *gameInfo, gData *gameData) {
+				gInfo.GameDuration = time.Second
+			},
+			want: "game took 3s instead of 1s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gInfo := fixtureGameInfo
+			gData := gameData{
+				Id:      fixtureGameData.Id,
+				Field:   closeFieldCopy(fixtureGameData.Field),
+				History: slices.Clone(fixtureGameData.History),
+			}
+			tt.change(&gInfo, &gData)
+
+			err := validateGame(gInfo, gData)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("valid game fails with %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error is %v, want one with %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"github.com/gdamore/tcell/v2"
+)
+
+// in the order settings shows them
+var settingsCellWidths = []string{"1", "2", "3"}
+var settingsGrids = []string{"OFF", "CELL", "FIVE"}
+
+// How field cells map to terminal columns and rows. Scrolling and the
+// cursor work in cells, only drawing and mouse clicks need columns.
+type fieldLayout struct {
+	// columns of one cell, at least as wide as the widest glyph
+	cellWidth int
+	// a grid line after every this many cells, none when 0
+	gridEvery int
+}
+
+func newFieldLayout(cellWidth int, grid string, glyphs glyphSet) fieldLayout {
+	l := fieldLayout{cellWidth: max(cellWidth, glyphs.cellWidth())}
+
+	switch grid {
+	case "CELL":
+		l.gridEvery = 1
+	case "FIVE":
+		l.gridEvery = 5
+	}
+
+	return l
+}
+
+func (a *app) fieldLayout() fieldLayout {
+	return newFieldLayout(a.settings.CellWidth, a.settings.Grid, a.glyphSet())
+}
+
+// grid lines between the first visible cell and the visible cell i, lines
+// belong to the field so they scroll with it
+func (l fieldLayout) gridLines(scroll, i int) int {
+	if l.gridEvery == 0 {
+		return 0
+	}
+
+	return (scroll+i)/l.gridEvery - scroll/l.gridEvery
+}
+
+// of the visible cell i from the field's edge, size is cellWidth for columns and 1 for rows
+func (l fieldLayout) cellOffset(scroll, i, size int) int {
+	return i*size + l.gridLines(scroll, i)
+}
+
+// Cells that always fit in space wherever the field is scrolled to, which
+// with lines every few cells can leave room for a part of one more.
+func (l fieldLayout) cellsFitting(space, size int) int {
+	n := 0
+	for {
+		lines := 0
+		if l.gridEvery > 0 {
+			lines = (n + l.gridEvery - 1) / l.gridEvery
+		}
+		if (n+1)*size+lines > space {
+			return n
+		}
+		n++
+	}
+}
+
+// visible cell at offset from the field's edge, false on a grid line or past count cells
+func (l fieldLayout) cellAt(scroll, offset, size, count int) (int, bool) {
+	for i := range count {
+		start := l.cellOffset(scroll, i, size)
+		if offset >= start && offset < start+size {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// Lines between the visible cells of a field scrolled to scrollX and
+// scrollY, where width and height are the visible cells.
+func (a *app) drawFieldGrid(l fieldLayout, xOffset, yOffset, scrollX, scrollY, width, height int, style tcell.Style) {
+	if l.gridEvery == 0 || width == 0 || height == 0 {
+		return
+	}
+
+	columns := l.cellOffset(scrollX, width-1, l.cellWidth) + l.cellWidth
+	rows := l.cellOffset(scrollY, height-1, 1) + 1
+
+	verticals := make([]bool, columns)
+	for i := 1; i < width; i++ {
+		if l.gridLines(scrollX, i) == l.gridLines(scrollX, i-1) {
+			continue
+		}
+
+		x := l.cellOffset(scrollX, i, l.cellWidth) - 1
+		verticals[x] = true
+		for y := range rows {
+			a.screen.SetContent(xOffset+x, yOffset+y, '│', nil, style)
+		}
+	}
+
+	for i := 1; i < height; i++ {
+		if l.gridLines(scrollY, i) == l.gridLines(scrollY, i-1) {
+			continue
+		}
+
+		y := l.cellOffset(scrollY, i, 1) - 1
+		for x := range columns {
+			rune := '─'
+			if verticals[x] {
+				rune = '┼'
+			}
+			a.screen.SetContent(xOffset+x, yOffset+y, rune, nil, style)
+		}
+	}
+}
@@ -2,36 +2,89 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/gdamore/tcell/v2"
 )
 
 func main() {
-	s, err := tcell.NewScreen()
+	dataFilePath, err := getDataFilePath()
 	if err != nil {
 		log.Fatalf("%+v", err)
 	}
-	defer safePanic(nil, s)
 
-	if err := s.Init(); err != nil {
+	store, err := openBoltStorage(dataFilePath)
+	if err != nil {
 		log.Fatalf("%+v", err)
 	}
+	defer store.close()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	err = store.autoBackup()
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
 
-	err = initSettings()
+	if len(os.Args) > 1 {
+		err = runCommand(store, os.Args[1:])
+		// exiting skips the deferred close, which releases the file lock
+		store.close()
+		if errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, COMMAND_USAGE)
+			os.Exit(2)
+		}
+		if err != nil {
+			log.Fatalf("%+v", err)
+		}
+		return
+	}
+
+	err = store.migrate()
 	if err != nil {
 		log.Fatalf("%+v", err)
 	}
 
-	sett, err := getSettings()
+	err = store.initSettings()
 	if err != nil {
 		log.Fatalf("%+v", err)
 	}
 
-	app := createApp(s, ctx, cancel, sett)
+	sett, err := store.getSettings()
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	err = purgeExpiredTrash(store, sett)
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	s, err := tcell.NewScreen()
+	if err != nil {
+		log.Fatalf("%+v", err)
+	}
+	defer safePanic(nil, s)
+
+	if err := s.Init(); err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	// broken user themes are only logged, built-in ones always load
+	themes, themesErr := loadThemes(colorModeColors(sett.ColorMode, s))
+	if len(themes) == 0 {
+		s.Fini()
+		log.Fatalf("%+v", themesErr)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	app := createApp(s, ctx, cancel, store, sett, themes)
+	if themesErr != nil {
+		app.log(themesErr)
+	}
 
 	app.createMenu()
 
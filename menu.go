@@ -2,17 +2,19 @@ package main
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/google/uuid"
 )
 
 type menu struct {
-	// SELECT,PLAY,SAVED_GAMES,SETTINGS
+	// SELECT,PLAY,SAVED_GAMES,TRASH,DATABASE,SETTINGS
 	menuState string
 
-	// PLAY,SAVED_GAMES,SETTINGS
+	// PLAY,SAVED_GAMES,TRASH,DATABASE,SETTINGS
 	selectState string
 
 	// WIDTH, HEIGHT, MINE_COUNT
@@ -23,10 +25,12 @@ type menu struct {
 
 	// PREPARE, FIND
 	savedGamesState string
-	savedGames      []gameInfo
-	// SORT_BY, FIELD
+	// FIND list, infos are loaded page by page into the cache when shown
+	savedGameIds    []uuid.UUID
+	savedGamesCache map[uuid.UUID]gameInfo
+	// SORT_BY, FIELD, RESULT, DATE, DURATION, DENSITY, SIZE, STARRED, TAG
 	savedGamesPrepareState string
-	// LATEST, OLDEST, BEST, WORST
+	// LATEST, OLDEST, BEST, WORST, STARRED
 	savedGamesPrepareSortByState string
 	// ALL, CUSTOM
 	savedGamesPrepareFieldState string
@@ -35,16 +39,99 @@ type menu struct {
 	savedGamesPrepareFieldCustomWidth     int
 	savedGamesPrepareFieldCustomHeight    int
 	savedGamesPrepareFieldCustomMineCount int
-	savedGamesFindCurr                    int
-	savedGamesFindScreenOffset            int
-	savedGamesFindLastMPress              time.Time
-	savedGamesFindLastMPressIndex         int
-
-	// THEME, MAX_SCROLLOFF
+	// ALL, WON, LOST
+	savedGamesPrepareResultState string
+	// ALL, TODAY, WEEK, CUSTOM
+	savedGamesPrepareDateState string
+	// FROM, TO
+	savedGamesPrepareDateCustomState string
+	// YYYYMMDD, 0 is unbounded like for every filter below
+	savedGamesPrepareDateCustomFrom int
+	savedGamesPrepareDateCustomTo   int
+	// MIN, MAX
+	savedGamesPrepareDurationState string
+	// seconds
+	savedGamesPrepareDurationMin int
+	savedGamesPrepareDurationMax int
+	// MIN, MAX
+	savedGamesPrepareDensityState string
+	// percent
+	savedGamesPrepareDensityMin int
+	savedGamesPrepareDensityMax int
+	// WIDTH_MIN, WIDTH_MAX, HEIGHT_MIN, HEIGHT_MAX
+	savedGamesPrepareSizeState     string
+	savedGamesPrepareSizeWidthMin  int
+	savedGamesPrepareSizeWidthMax  int
+	savedGamesPrepareSizeHeightMin int
+	savedGamesPrepareSizeHeightMax int
+	// ALL, STARRED
+	savedGamesPrepareStarredState string
+	// empty for all games, otherwise one of savedGamesPrepareTags
+	savedGamesPrepareTag  string
+	savedGamesPrepareTags []string
+	// why the filters weren't applied, cleared by the next key
+	savedGamesPrepareMessage string
+	// row of the FIND list, when searching rows are only the matches
+	savedGamesFindCurr            int
+	savedGamesFindScreenOffset    int
+	savedGamesFindLastMPress      time.Time
+	savedGamesFindLastMPressIndex int
+	savedGamesFindLastGPress      time.Time
+	// typing the search after /
+	savedGamesFindSearchActive bool
+	savedGamesFindSearch       string
+	// indexes into savedGameIds of rows matching the search, of the first
+	// savedGamesFindSearched ids, the rest are searched as the list moves on
+	savedGamesFindMatches  []int
+	savedGamesFindSearched int
+	savedGamesFindMarked   map[uuid.UUID]bool
+	// row v was pressed on, -1 when not selecting a range
+	savedGamesFindVisualStart int
+	// NONE, CONFIRM, PENDING
+	savedGamesFindBulkState     string
+	savedGamesFindDeletePending []uuid.UUID
+	savedGamesFindDeleteAt      time.Time
+	savedGamesFindDeleteUndo    chan struct{}
+
+	trash                []trashedGame
+	trashCurr            int
+	trashScreenOffset    int
+	trashLastMPress      time.Time
+	trashLastMPressIndex int
+
+	// BACKUP, RESTORE, COMPACT, IMPORT
+	databaseState string
+	// NONE, LIST, CONFIRM
+	databaseRestoreState string
+	// newest first
+	databaseBackups             []string
+	databaseBackupsCurr         int
+	databaseBackupsScreenOffset int
+	// typing the path after Import
+	databaseImportActive bool
+	databaseImportPath   string
+	// outcome of the last action
+	databaseMessage string
+
+	// THEME, MAX_SCROLLOFF, TRASH_RETENTION_DAYS, COLOR_MODE, DISTINCT_CELLS, GLYPHS, CELL_WIDTH, GRID, STATUS_LINE, TIMER
 	settingsState string
-	// DEFAULT, LIGHT, DARK, MONO
-	settingsThemeState   string
-	settingsMaxScrolloff int
+	// key of one of the themes
+	settingsThemeState         string
+	settingsMaxScrolloff       int
+	settingsTrashRetentionDays int
+	// AUTO, TRUECOLOR, 256, 16, 8
+	settingsColorMode     string
+	settingsDistinctCells bool
+	// ASCII, UNICODE, NERD
+	settingsGlyphs    string
+	settingsCellWidth int
+	// OFF, CELL, FIVE
+	settingsGrid string
+	// chosen segments in order
+	settingsStatusLine []string
+	// index into statusLineOptions of settingsStatusLine
+	settingsStatusLineCurr int
+	settingsTimerDecimals  int
 }
 
 func (a *app) createMenu() {
@@ -59,7 +146,8 @@ func (a *app) createMenu() {
 		playMineCount: 0,
 
 		savedGamesState:                       "PREPARE",
-		savedGames:                            []gameInfo{},
+		savedGameIds:                          []uuid.UUID{},
+		savedGamesCache:                       map[uuid.UUID]gameInfo{},
 		savedGamesPrepareState:                "SORT_BY",
 		savedGamesPrepareSortByState:          "LATEST",
 		savedGamesPrepareFieldState:           "ALL",
@@ -67,14 +155,65 @@ func (a *app) createMenu() {
 		savedGamesPrepareFieldCustomWidth:     0,
 		savedGamesPrepareFieldCustomHeight:    0,
 		savedGamesPrepareFieldCustomMineCount: 0,
+		savedGamesPrepareResultState:          "ALL",
+		savedGamesPrepareDateState:            "ALL",
+		savedGamesPrepareDateCustomState:      "FROM",
+		savedGamesPrepareDateCustomFrom:       0,
+		savedGamesPrepareDateCustomTo:         0,
+		savedGamesPrepareDurationState:        "MIN",
+		savedGamesPrepareDurationMin:          0,
+		savedGamesPrepareDurationMax:          0,
+		savedGamesPrepareDensityState:         "MIN",
+		savedGamesPrepareDensityMin:           0,
+		savedGamesPrepareDensityMax:           0,
+		savedGamesPrepareSizeState:            "WIDTH_MIN",
+		savedGamesPrepareSizeWidthMin:         0,
+		savedGamesPrepareSizeWidthMax:         0,
+		savedGamesPrepareSizeHeightMin:        0,
+		savedGamesPrepareSizeHeightMax:        0,
+		savedGamesPrepareStarredState:         "ALL",
+		savedGamesPrepareTag:                  "",
+		savedGamesPrepareTags:                 []string{},
 		savedGamesFindCurr:                    0,
 		savedGamesFindScreenOffset:            0,
 		savedGamesFindLastMPress:              time.Now().Add(-time.Minute),
 		savedGamesFindLastMPressIndex:         -1,
-
-		settingsState:        "THEME",
-		settingsThemeState:   a.settings.Theme,
-		settingsMaxScrolloff: a.settings.MaxScrolloff,
+		savedGamesFindLastGPress:              time.Now().Add(-time.Minute),
+		savedGamesFindSearchActive:            false,
+		savedGamesFindSearch:                  "",
+		savedGamesFindMatches:                 []int{},
+		savedGamesFindMarked:                  map[uuid.UUID]bool{},
+		savedGamesFindVisualStart:             -1,
+		savedGamesFindBulkState:               "NONE",
+		savedGamesFindDeletePending:           []uuid.UUID{},
+
+		trash:                []trashedGame{},
+		trashCurr:            0,
+		trashScreenOffset:    0,
+		trashLastMPress:      time.Now().Add(-time.Minute),
+		trashLastMPressIndex: -1,
+
+		databaseState:               "BACKUP",
+		databaseRestoreState:        "NONE",
+		databaseBackups:             []string{},
+		databaseBackupsCurr:         0,
+		databaseBackupsScreenOffset: 0,
+		databaseImportActive:        false,
+		databaseImportPath:          "",
+		databaseMessage:             "",
+
+		settingsState:              "THEME",
+		settingsThemeState:         a.settings.Theme,
+		settingsMaxScrolloff:       a.settings.MaxScrolloff,
+		settingsTrashRetentionDays: a.settings.TrashRetentionDays,
+		settingsColorMode:          a.settings.ColorMode,
+		settingsDistinctCells:      a.settings.DistinctCells,
+		settingsGlyphs:             a.settings.Glyphs,
+		settingsCellWidth:          a.settings.CellWidth,
+		settingsGrid:               a.settings.Grid,
+		settingsStatusLine:         slices.Clone(a.settings.StatusLine),
+		settingsStatusLineCurr:     0,
+		settingsTimerDecimals:      a.settings.TimerDecimals,
 	}
 }
 
@@ -86,6 +225,10 @@ func (a *app) drawMenu() {
 		a.drawMenuPlay()
 	case "SAVED_GAMES":
 		a.drawMenuSavedGames()
+	case "TRASH":
+		a.drawMenuTrash()
+	case "DATABASE":
+		a.drawMenuDatabase()
 	case "SETTINGS":
 		a.drawMenuSettings()
 	}
@@ -95,6 +238,17 @@ func (a *app) eventKeyMenu(ev *tcell.EventKey) {
 	key := ev.Key()
 	rune := ev.Rune()
 
+	// search and paths can contain q, Esc only stops them
+	if a.menu.menuState == "SAVED_GAMES" && a.menu.savedGamesState == "FIND" && a.menu.savedGamesFindSearchActive {
+		a.eventKeyMenuSavedGamesFindSearch(key, rune)
+		return
+	}
+
+	if a.menu.menuState == "DATABASE" && a.menu.databaseImportActive {
+		a.eventKeyMenuDatabaseImport(key, rune)
+		return
+	}
+
 	if key == tcell.KeyEscape || rune == 'q' {
 		a.cancel()
 		return
@@ -107,6 +261,10 @@ func (a *app) eventKeyMenu(ev *tcell.EventKey) {
 		a.eventKeyMenuPlay(key, rune)
 	case "SAVED_GAMES":
 		a.eventKeyMenuSavedGames(key, rune)
+	case "TRASH":
+		a.eventKeyMenuTrash(key, rune)
+	case "DATABASE":
+		a.eventKeyMenuDatabase(key, rune)
 	case "SETTINGS":
 		a.eventKeyMenuSettings(key, rune)
 	}
@@ -116,15 +274,21 @@ func (a *app) drawMenuSelect() {
 	a.setContentString(0, 0, a.defStyle, "Termines")
 	a.setContentString(0, 1, a.defStyle, "Play")
 	a.setContentString(0, 2, a.defStyle, "Saved Games")
-	a.setContentString(0, 3, a.defStyle, "Settings")
+	a.setContentString(0, 3, a.defStyle, "Trash")
+	a.setContentString(0, 4, a.defStyle, "Database")
+	a.setContentString(0, 5, a.defStyle, "Settings")
 
 	switch a.menu.selectState {
 	case "PLAY":
 		a.setContentString(0, 1, a.defStyle.Reverse(true), "Play")
 	case "SAVED_GAMES":
 		a.setContentString(0, 2, a.defStyle.Reverse(true), "Saved Games")
+	case "TRASH":
+		a.setContentString(0, 3, a.defStyle.Reverse(true), "Trash")
+	case "DATABASE":
+		a.setContentString(0, 4, a.defStyle.Reverse(true), "Database")
 	case "SETTINGS":
-		a.setContentString(0, 3, a.defStyle.Reverse(true), "Settings")
+		a.setContentString(0, 5, a.defStyle.Reverse(true), "Settings")
 	}
 }
 
@@ -230,6 +394,13 @@ func (a *app) drawMenuSavedGamesPrepare() {
 	}
 	currStart += len(worstStr) + 1
 
+	starredStr := "Starred"
+	a.setContentString(currStart, 2, a.defStyle, starredStr)
+	if a.menu.savedGamesPrepareSortByState == "STARRED" {
+		a.setContentString(currStart, 2, a.defStyle.Reverse(true), starredStr)
+	}
+	currStart += len(starredStr) + 1
+
 	// FIELD
 	a.setContentString(0, 3, a.defStyle, "Field:")
 	if a.menu.savedGamesPrepareState == "FIELD" {
@@ -287,6 +458,12 @@ func (a *app) drawMenuSavedGamesPrepare() {
 			}
 		}
 	}
+
+	if a.menu.savedGamesPrepareFieldState == "CUSTOM" {
+		a.drawMenuSavedGamesPrepareFilters(8)
+	} else {
+		a.drawMenuSavedGamesPrepareFilters(5)
+	}
 }
 
 func (a *app) drawMenuSavedGamesFind() {
@@ -301,32 +478,37 @@ func (a *app) drawMenuSavedGamesFind() {
 	}
 	infoStr += " "
 	infoStr += a.menu.savedGamesPrepareSortByState
+	if filtersStr := a.savedGamesFiltersStr(); filtersStr != "" {
+		infoStr += " " + filtersStr
+	}
 	a.setContentString(0, 0, a.defStyle, "Saved Games"+" "+infoStr)
-	_, screenHeight := a.screen.Size()
-	screenHeight -= 1
-
-	for i := range screenHeight {
-		idx := i + a.menu.savedGamesFindScreenOffset
-		if idx < len(a.menu.savedGames) {
-			v := a.menu.savedGames[idx]
 
-			num := strconv.Itoa(idx + 1)
-			width := strconv.Itoa(v.FieldWidth)
-			height := strconv.Itoa(v.FieldHeight)
-			mineCount := strconv.Itoa(v.MineCount)
-			time := strconv.Itoa(int(v.GameDuration.Seconds()))
-
-			date := v.CreatedAt.Format("2006-01-02 15:04:05")
+	listHeight := a.savedGamesFindListHeight()
+	for i := range listHeight {
+		row := i + a.menu.savedGamesFindScreenOffset
+		if row < a.savedGamesFindLen() {
+			idx := a.savedGamesFindIdx(row)
+			v, err := a.savedGameInfo(idx)
+			if err != nil {
+				a.log(err)
+				return
+			}
 
-			str := num + "." + v.Result + " " + width + "x" + height + "(" + mineCount + ")" + " " + time + " " + date
+			separator := "."
+			if a.isSavedGamesFindRowMarked(row) {
+				separator = "*"
+			}
+			str := strconv.Itoa(idx+1) + separator + savedGameRowStr(v)
 
-			if idx == a.menu.savedGamesFindCurr {
+			if row == a.menu.savedGamesFindCurr {
 				a.setContentString(0, i+1, a.defStyle.Reverse(true), str)
 			} else {
 				a.setContentString(0, i+1, a.defStyle, str)
 			}
 		}
 	}
+
+	a.drawMenuSavedGamesFindFooter()
 }
 
 func (a *app) drawMenuSettings() {
@@ -334,45 +516,71 @@ func (a *app) drawMenuSettings() {
 
 	a.setContentString(0, 1, a.defStyle, "Theme")
 	a.setContentString(0, 3, a.defStyle, "MAX_SCROLLOFF")
+	a.setContentString(0, 5, a.defStyle, "Trash Retention Days")
+	a.setContentString(0, 7, a.defStyle, "Colors")
+	a.setContentString(0, 9, a.defStyle, "Distinct Cells")
+	a.setContentString(0, 11, a.defStyle, "Glyphs")
+	a.setContentString(0, 13, a.defStyle, "Cell Width")
+	a.setContentString(0, 15, a.defStyle, "Grid")
+	a.setContentString(0, 17, a.defStyle, "Status Line")
+	a.setContentString(0, 19, a.defStyle, "Timer")
 	switch a.menu.settingsState {
 	case "THEME":
 		a.setContentString(0, 1, a.defStyle.Reverse(true), "Theme")
 	case "MAX_SCROLLOFF":
 		a.setContentString(0, 3, a.defStyle.Reverse(true), "MAX_SCROLLOFF")
+	case "TRASH_RETENTION_DAYS":
+		a.setContentString(0, 5, a.defStyle.Reverse(true), "Trash Retention Days")
+	case "COLOR_MODE":
+		a.setContentString(0, 7, a.defStyle.Reverse(true), "Colors")
+	case "DISTINCT_CELLS":
+		a.setContentString(0, 9, a.defStyle.Reverse(true), "Distinct Cells")
+	case "GLYPHS":
+		a.setContentString(0, 11, a.defStyle.Reverse(true), "Glyphs")
+	case "CELL_WIDTH":
+		a.setContentString(0, 13, a.defStyle.Reverse(true), "Cell Width")
+	case "GRID":
+		a.setContentString(0, 15, a.defStyle.Reverse(true), "Grid")
+	case "STATUS_LINE":
+		a.setContentString(0, 17, a.defStyle.Reverse(true), "Status Line")
+		a.setContentString(12, 17, a.defStyle, "x show or hide, H L move")
+	case "TIMER":
+		a.setContentString(0, 19, a.defStyle.Reverse(true), "Timer")
 	}
 
-	currStart := 0
-
-	defaultThemeStr := "Default"
-	a.setContentString(currStart, 2, a.defStyle, defaultThemeStr)
-	if a.menu.settingsThemeState == "DEFAULT" {
-		a.setContentString(currStart, 2, a.defStyle.Reverse(true), defaultThemeStr)
+	themeNames := make([]string, len(a.themes))
+	themeKeys := make([]string, len(a.themes))
+	for i, t := range a.themes {
+		themeNames[i] = t.Name
+		themeKeys[i] = t.Key
 	}
-	currStart += len(defaultThemeStr) + 1
+	a.drawMenuOptions(2, themeNames, themeKeys, a.menu.settingsThemeState)
 
-	lightThemeStr := "Light"
-	a.setContentString(currStart, 2, a.defStyle, lightThemeStr)
-	if a.menu.settingsThemeState == "LIGHT" {
-		a.setContentString(currStart, 2, a.defStyle.Reverse(true), lightThemeStr)
-	}
-	currStart += len(lightThemeStr) + 1
+	maxScrolloffStr := strconv.Itoa(a.menu.settingsMaxScrolloff)
+	a.setContentString(0, 4, a.defStyle, maxScrolloffStr)
 
-	darkThemeStr := "Dark"
-	a.setContentString(currStart, 2, a.defStyle, darkThemeStr)
-	if a.menu.settingsThemeState == "DARK" {
-		a.setContentString(currStart, 2, a.defStyle.Reverse(true), darkThemeStr)
+	trashRetentionDaysStr := strconv.Itoa(a.menu.settingsTrashRetentionDays)
+	if a.menu.settingsTrashRetentionDays == 0 {
+		trashRetentionDaysStr = "Never purge"
 	}
-	currStart += len(darkThemeStr) + 1
+	a.setContentString(0, 6, a.defStyle, trashRetentionDaysStr)
 
-	monoThemeStr := "Mono"
-	a.setContentString(currStart, 2, a.defStyle, monoThemeStr)
-	if a.menu.settingsThemeState == "MONO" {
-		a.setContentString(currStart, 2, a.defStyle.Reverse(true), monoThemeStr)
-	}
-	currStart += len(monoThemeStr) + 1
+	autoStr := colorModeStr("AUTO", a.screen.Colors())
+	a.drawMenuOptions(8, []string{autoStr, "Truecolor", "256", "16", "8"}, settingsColorModes, a.menu.settingsColorMode)
 
-	maxScrolloffStr := strconv.Itoa(a.menu.settingsMaxScrolloff)
-	a.setContentString(0, 4, a.defStyle, maxScrolloffStr)
+	a.drawMenuOptions(10, []string{"Off", "On"}, []string{"false", "true"}, strconv.FormatBool(a.menu.settingsDistinctCells))
+
+	a.drawMenuOptions(12, []string{"ASCII", "Unicode", "Nerd Font"}, settingsGlyphSets, a.menu.settingsGlyphs)
+
+	a.drawMenuOptions(14, settingsCellWidths, settingsCellWidths, strconv.Itoa(a.menu.settingsCellWidth))
+
+	a.drawMenuOptions(16, []string{"Off", "Every Cell", "Every 5 Cells"}, settingsGrids, a.menu.settingsGrid)
+
+	a.drawMenuStatusLine(18)
+
+	a.drawMenuOptions(20, []string{"Seconds", "Tenths", "Hundredths"}, settingsTimerDecimals, strconv.Itoa(a.menu.settingsTimerDecimals))
+
+	a.drawSettingsThemePreview(22)
 }
 
 func (a *app) eventKeyMenuSelect(key tcell.Key, rune rune) {
@@ -381,9 +589,32 @@ func (a *app) eventKeyMenuSelect(key tcell.Key, rune rune) {
 		case "PLAY":
 			a.menu.menuState = "PLAY"
 		case "SAVED_GAMES":
+			err := a.reloadSavedGamesTags()
+			if err != nil {
+				a.log(err)
+				a.cancel()
+				return
+			}
 			a.menu.savedGamesState = "PREPARE"
 			a.menu.menuState = "SAVED_GAMES"
+		case "TRASH":
+			a.menu.trashCurr = 0
+			a.menu.trashScreenOffset = 0
+			err := a.reloadTrash()
+			if err != nil {
+				a.log(err)
+				a.cancel()
+				return
+			}
+			a.menu.menuState = "TRASH"
+		case "DATABASE":
+			a.menu.databaseState = "BACKUP"
+			a.menu.databaseRestoreState = "NONE"
+			a.menu.databaseImportActive = false
+			a.menu.databaseMessage = ""
+			a.menu.menuState = "DATABASE"
 		case "SETTINGS":
+			a.reloadThemes()
 			a.menu.menuState = "SETTINGS"
 		}
 	}
@@ -393,6 +624,10 @@ func (a *app) eventKeyMenuSelect(key tcell.Key, rune rune) {
 		case "PLAY":
 			a.menu.selectState = "SAVED_GAMES"
 		case "SAVED_GAMES":
+			a.menu.selectState = "TRASH"
+		case "TRASH":
+			a.menu.selectState = "DATABASE"
+		case "DATABASE":
 			a.menu.selectState = "SETTINGS"
 		case "SETTINGS":
 			a.menu.selectState = "PLAY"
@@ -405,8 +640,12 @@ func (a *app) eventKeyMenuSelect(key tcell.Key, rune rune) {
 			a.menu.selectState = "SETTINGS"
 		case "SAVED_GAMES":
 			a.menu.selectState = "PLAY"
-		case "SETTINGS":
+		case "TRASH":
 			a.menu.selectState = "SAVED_GAMES"
+		case "DATABASE":
+			a.menu.selectState = "TRASH"
+		case "SETTINGS":
+			a.menu.selectState = "DATABASE"
 		}
 	}
 }
@@ -434,7 +673,7 @@ func (a *app) eventKeyMenuPlay(key tcell.Key, rune rune) {
 		case "WIDTH":
 			if validPlay {
 				a.play = createPlay(a.menu.playWidth, a.menu.playHeight, a.menu.playMineCount)
-				a.state = "PLAY"
+				a.setState("PLAY")
 				break
 			}
 			if a.menu.playWidth == 0 {
@@ -449,7 +688,7 @@ func (a *app) eventKeyMenuPlay(key tcell.Key, rune rune) {
 		case "HEIGHT":
 			if validPlay {
 				a.play = createPlay(a.menu.playWidth, a.menu.playHeight, a.menu.playMineCount)
-				a.state = "PLAY"
+				a.setState("PLAY")
 				break
 			}
 			if a.menu.playHeight == 0 {
@@ -463,7 +702,7 @@ func (a *app) eventKeyMenuPlay(key tcell.Key, rune rune) {
 		case "MINE_COUNT":
 			if validPlay {
 				a.play = createPlay(a.menu.playWidth, a.menu.playHeight, a.menu.playMineCount)
-				a.state = "PLAY"
+				a.setState("PLAY")
 				break
 			}
 			if a.menu.playMineCount == 0 {
@@ -521,6 +760,8 @@ func (a *app) eventKeyMenuSavedGames(key tcell.Key, rune rune) {
 }
 
 func (a *app) eventKeyMenuSavedGamesPrepare(key tcell.Key, rune rune) {
+	a.menu.savedGamesPrepareMessage = ""
+
 	if key == tcell.KeyBackspace || key == tcell.KeyBackspace2 {
 		if a.menu.savedGamesPrepareState == "FIELD" && a.menu.savedGamesPrepareFieldState == "CUSTOM" {
 			switch a.menu.savedGamesPrepareFieldCustomState {
@@ -541,6 +782,10 @@ func (a *app) eventKeyMenuSavedGamesPrepare(key tcell.Key, rune rune) {
 	}
 
 	if key == tcell.KeyEnter || key == tcell.KeyTab || rune == ' ' || rune == 'd' {
+		if !a.validSavedGamesPrepareDates() || !a.validSavedGamesPrepareBounds() {
+			return
+		}
+
 		validInfo :=
 			(a.menu.savedGamesPrepareFieldCustomWidth != 0 &&
 				a.menu.savedGamesPrepareFieldCustomHeight != 0 &&
@@ -548,19 +793,16 @@ func (a *app) eventKeyMenuSavedGamesPrepare(key tcell.Key, rune rune) {
 				a.menu.savedGamesPrepareFieldState == "ALL"
 
 		if validInfo {
-			savedGames, err := a.loadGameInfos(
-				a.menu.savedGamesPrepareSortByState,
-				a.menu.savedGamesPrepareFieldState == "ALL",
-				a.menu.savedGamesPrepareFieldCustomWidth,
-				a.menu.savedGamesPrepareFieldCustomHeight,
-				a.menu.savedGamesPrepareFieldCustomMineCount,
-			)
+			a.menu.savedGamesFindSearchActive = false
+			a.menu.savedGamesFindSearch = ""
+			a.menu.savedGamesFindMarked = map[uuid.UUID]bool{}
+			a.menu.savedGamesFindVisualStart = -1
+			err := a.reloadSavedGames()
 			if err != nil {
 				a.log(err)
 				a.cancel()
 				return
 			}
-			a.menu.savedGames = savedGames
 			a.menu.savedGamesFindCurr = 0
 			a.menu.savedGamesFindScreenOffset = 0
 			a.menu.savedGamesState = "FIND"
@@ -618,45 +860,11 @@ func (a *app) eventKeyMenuSavedGamesPrepare(key tcell.Key, rune rune) {
 	}
 
 	if rune == 'k' || key == tcell.KeyUp {
-		switch a.menu.savedGamesPrepareState {
-		case "SORT_BY":
-			a.menu.savedGamesPrepareFieldCustomState = "MINE_COUNT"
-			a.menu.savedGamesPrepareState = "FIELD"
-		case "FIELD":
-			if a.menu.savedGamesPrepareFieldState == "CUSTOM" {
-				switch a.menu.savedGamesPrepareFieldCustomState {
-				case "WIDTH":
-					a.menu.savedGamesPrepareState = "SORT_BY"
-				case "HEIGHT":
-					a.menu.savedGamesPrepareFieldCustomState = "WIDTH"
-				case "MINE_COUNT":
-					a.menu.savedGamesPrepareFieldCustomState = "HEIGHT"
-				}
-			} else {
-				a.menu.savedGamesPrepareState = "SORT_BY"
-			}
-		}
+		a.moveSavedGamesPrepareRow(-1)
 	}
 
 	if rune == 'j' || key == tcell.KeyDown {
-		switch a.menu.savedGamesPrepareState {
-		case "SORT_BY":
-			a.menu.savedGamesPrepareFieldCustomState = "WIDTH"
-			a.menu.savedGamesPrepareState = "FIELD"
-		case "FIELD":
-			if a.menu.savedGamesPrepareFieldState == "CUSTOM" {
-				switch a.menu.savedGamesPrepareFieldCustomState {
-				case "WIDTH":
-					a.menu.savedGamesPrepareFieldCustomState = "HEIGHT"
-				case "HEIGHT":
-					a.menu.savedGamesPrepareFieldCustomState = "MINE_COUNT"
-				case "MINE_COUNT":
-					a.menu.savedGamesPrepareState = "SORT_BY"
-				}
-			} else {
-				a.menu.savedGamesPrepareState = "SORT_BY"
-			}
-		}
+		a.moveSavedGamesPrepareRow(1)
 	}
 
 	if rune == 'l' || key == tcell.KeyRight {
@@ -670,6 +878,8 @@ func (a *app) eventKeyMenuSavedGamesPrepare(key tcell.Key, rune rune) {
 			case "BEST":
 				a.menu.savedGamesPrepareSortByState = "WORST"
 			case "WORST":
+				a.menu.savedGamesPrepareSortByState = "STARRED"
+			case "STARRED":
 				a.menu.savedGamesPrepareSortByState = "LATEST"
 			}
 		case "FIELD":
@@ -680,6 +890,8 @@ func (a *app) eventKeyMenuSavedGamesPrepare(key tcell.Key, rune rune) {
 			case "CUSTOM":
 				a.menu.savedGamesPrepareFieldState = "ALL"
 			}
+		default:
+			a.cycleSavedGamesPrepareFilter(1)
 		}
 	}
 
@@ -688,13 +900,15 @@ func (a *app) eventKeyMenuSavedGamesPrepare(key tcell.Key, rune rune) {
 		case "SORT_BY":
 			switch a.menu.savedGamesPrepareSortByState {
 			case "LATEST":
-				a.menu.savedGamesPrepareSortByState = "WORST"
+				a.menu.savedGamesPrepareSortByState = "STARRED"
 			case "OLDEST":
 				a.menu.savedGamesPrepareSortByState = "LATEST"
 			case "BEST":
 				a.menu.savedGamesPrepareSortByState = "OLDEST"
 			case "WORST":
 				a.menu.savedGamesPrepareSortByState = "BEST"
+			case "STARRED":
+				a.menu.savedGamesPrepareSortByState = "WORST"
 			}
 		case "FIELD":
 			switch a.menu.savedGamesPrepareFieldState {
@@ -704,6 +918,8 @@ func (a *app) eventKeyMenuSavedGamesPrepare(key tcell.Key, rune rune) {
 			case "CUSTOM":
 				a.menu.savedGamesPrepareFieldState = "ALL"
 			}
+		default:
+			a.cycleSavedGamesPrepareFilter(-1)
 		}
 	}
 
@@ -720,12 +936,20 @@ func (a *app) eventKeyMenuSavedGamesPrepare(key tcell.Key, rune rune) {
 			}
 		}
 	}
+
+	a.eventKeyMenuSavedGamesPrepareFilters(key, rune)
 }
 
 func (a *app) eventKeyMenuSavedGamesFind(key tcell.Key, rune rune) {
+	// y or anything else answers the bulk delete question
+	if a.menu.savedGamesFindBulkState == "CONFIRM" {
+		a.eventKeyMenuSavedGamesFindBulk(key, rune)
+		return
+	}
+
 	if key == tcell.KeyEnter || key == tcell.KeyTab || rune == ' ' || rune == 'd' {
-		if len(a.menu.savedGames) > 0 {
-			i, d, err := a.loadGameInfoAndData(a.menu.savedGames[a.menu.savedGamesFindCurr].Id)
+		if a.savedGamesFindLen() > 0 {
+			i, d, err := a.loadGameInfoAndData(a.menu.savedGameIds[a.savedGamesFindIdx(a.menu.savedGamesFindCurr)])
 			if err != nil {
 				a.log(err)
 				a.cancel()
@@ -733,40 +957,31 @@ func (a *app) eventKeyMenuSavedGamesFind(key tcell.Key, rune rune) {
 			a.replay.gInfo = i
 			a.replay.gData = d
 
+			a.setState("REPLAY")
 			a.replay.rInfo = a.createReplayInfo(a.replay.gData)
-			a.state = "REPLAY"
 		}
 	}
 	if rune == 'm' {
 		if time.Since(a.menu.savedGamesFindLastMPress).Abs() < time.Second/2 &&
 			a.menu.savedGamesFindLastMPressIndex == a.menu.savedGamesFindCurr {
-			if len(a.menu.savedGames) == 0 {
+			if a.savedGamesFindLen() == 0 {
 				return
 			}
 
-			err := a.deleteGame(a.menu.savedGames[a.menu.savedGamesFindCurr].Id)
+			err := a.deleteGame(a.menu.savedGameIds[a.savedGamesFindIdx(a.menu.savedGamesFindCurr)])
 			if err != nil {
 				a.log(err)
 				a.cancel()
 				return
 			}
 
-			savedGames, err := a.loadGameInfos(
-				a.menu.savedGamesPrepareSortByState,
-				a.menu.savedGamesPrepareFieldState == "ALL",
-				a.menu.savedGamesPrepareFieldCustomWidth,
-				a.menu.savedGamesPrepareFieldCustomHeight,
-				a.menu.savedGamesPrepareFieldCustomMineCount,
-			)
-
+			err = a.reloadSavedGames()
 			if err != nil {
 				a.log(err)
 				a.cancel()
 				return
 			}
-			a.menu.savedGames = savedGames
-			a.menu.savedGamesFindCurr = min(a.menu.savedGamesFindCurr, len(savedGames)-1)
-			a.menu.savedGamesFindCurr = max(a.menu.savedGamesFindCurr, 0)
+			a.alignSavedGamesFind()
 		} else {
 			a.menu.savedGamesFindLastMPress = time.Now()
 			a.menu.savedGamesFindLastMPressIndex = a.menu.savedGamesFindCurr
@@ -774,61 +989,71 @@ func (a *app) eventKeyMenuSavedGamesFind(key tcell.Key, rune rune) {
 	}
 
 	if rune == 'b' {
+		err := a.reloadSavedGamesTags()
+		if err != nil {
+			a.log(err)
+			a.cancel()
+			return
+		}
 		a.menu.savedGamesState = "PREPARE"
 		return
 	}
 
+	if rune == '/' {
+		a.menu.savedGamesFindSearchActive = true
+		return
+	}
+
 	if rune == 'j' || key == tcell.KeyDown {
-		_, screenHeight := a.screen.Size()
-		screenHeight -= 1
+		err := a.extendSavedGamesSearch(a.menu.savedGamesFindCurr + 1)
+		if err != nil {
+			a.log(err)
+			a.cancel()
+			return
+		}
 
-		if len(a.menu.savedGames) == 0 {
+		if a.savedGamesFindLen() == 0 {
 			return
 		}
 
-		if a.menu.savedGamesFindCurr == len(a.menu.savedGames)-1 {
-			a.menu.savedGamesFindCurr = 0
-			a.menu.savedGamesFindScreenOffset = 0
-		} else {
+		if a.menu.savedGamesFindCurr < a.savedGamesFindLen()-1 {
 			a.menu.savedGamesFindCurr++
-			screenIdx := a.menu.savedGamesFindCurr - a.menu.savedGamesFindScreenOffset
-			if screenIdx >= screenHeight {
-				a.menu.savedGamesFindScreenOffset++
-			}
+		} else if !a.savedGamesSearchPartial() {
+			a.menu.savedGamesFindCurr = 0
 		}
+		a.alignSavedGamesFind()
 	}
 
 	if rune == 'k' || key == tcell.KeyUp {
-		_, screenHeight := a.screen.Size()
-		screenHeight -= 1
-		if len(a.menu.savedGames) == 0 {
+		if a.savedGamesFindLen() == 0 {
 			return
 		}
 
 		if a.menu.savedGamesFindCurr == 0 {
-			if len(a.menu.savedGames) >= screenHeight {
-				a.menu.savedGamesFindCurr = len(a.menu.savedGames) - 1
-				a.menu.savedGamesFindScreenOffset = len(a.menu.savedGames) - screenHeight
-			} else {
-				a.menu.savedGamesFindCurr = len(a.menu.savedGames) - 1
-				a.menu.savedGamesFindScreenOffset = 0
-			}
+			a.menu.savedGamesFindCurr = a.savedGamesFindLen() - 1
 		} else {
 			a.menu.savedGamesFindCurr--
-			screenIdx := a.menu.savedGamesFindCurr - a.menu.savedGamesFindScreenOffset
-			if screenIdx < 0 {
-				a.menu.savedGamesFindScreenOffset--
-			}
 		}
+		a.alignSavedGamesFind()
 	}
+
+	a.eventKeyMenuSavedGamesFindJump(key, rune)
+	a.eventKeyMenuSavedGamesFindBulk(key, rune)
 }
 
 func (a *app) eventKeyMenuSettings(key tcell.Key, rune rune) {
 	if key == tcell.KeyEnter || key == tcell.KeyTab || rune == ' ' || rune == 'd' {
-		newSettings := settings{
-			Theme:        a.menu.settingsThemeState,
-			MaxScrolloff: a.menu.settingsMaxScrolloff,
-		}
+		newSettings := a.settings
+		newSettings.Theme = a.menu.settingsThemeState
+		newSettings.MaxScrolloff = a.menu.settingsMaxScrolloff
+		newSettings.TrashRetentionDays = a.menu.settingsTrashRetentionDays
+		newSettings.ColorMode = a.menu.settingsColorMode
+		newSettings.DistinctCells = a.menu.settingsDistinctCells
+		newSettings.Glyphs = a.menu.settingsGlyphs
+		newSettings.CellWidth = a.menu.settingsCellWidth
+		newSettings.Grid = a.menu.settingsGrid
+		newSettings.StatusLine = slices.Clone(a.menu.settingsStatusLine)
+		newSettings.TimerDecimals = a.menu.settingsTimerDecimals
 
 		err := a.updateSettings(newSettings)
 		if err != nil {
@@ -838,15 +1063,9 @@ func (a *app) eventKeyMenuSettings(key tcell.Key, rune rune) {
 		}
 		a.settings = newSettings
 
-		defStyle := tcell.StyleDefault.Background(tcell.ColorReset).Foreground(tcell.ColorReset)
-		switch newSettings.Theme {
-		case "LIGHT":
-			defStyle = tcell.StyleDefault.Background(tcell.Color254).Foreground(tcell.Color234)
-		case "DARK":
-			defStyle = tcell.StyleDefault.Background(tcell.Color234).Foreground(tcell.Color254)
-		}
-		a.defStyle = defStyle
-		a.screen.SetStyle(defStyle)
+		// themes are fitted to the colour mode
+		a.reloadThemes()
+		a.setTheme(newSettings.Theme)
 
 		a.screen.Clear()
 		a.draw()
@@ -866,6 +1085,22 @@ func (a *app) eventKeyMenuSettings(key tcell.Key, rune rune) {
 		case "THEME":
 			a.menu.settingsState = "MAX_SCROLLOFF"
 		case "MAX_SCROLLOFF":
+			a.menu.settingsState = "TRASH_RETENTION_DAYS"
+		case "TRASH_RETENTION_DAYS":
+			a.menu.settingsState = "COLOR_MODE"
+		case "COLOR_MODE":
+			a.menu.settingsState = "DISTINCT_CELLS"
+		case "DISTINCT_CELLS":
+			a.menu.settingsState = "GLYPHS"
+		case "GLYPHS":
+			a.menu.settingsState = "CELL_WIDTH"
+		case "CELL_WIDTH":
+			a.menu.settingsState = "GRID"
+		case "GRID":
+			a.menu.settingsState = "STATUS_LINE"
+		case "STATUS_LINE":
+			a.menu.settingsState = "TIMER"
+		case "TIMER":
 			a.menu.settingsState = "THEME"
 		}
 	}
@@ -873,49 +1108,89 @@ func (a *app) eventKeyMenuSettings(key tcell.Key, rune rune) {
 	if rune == 'k' || key == tcell.KeyUp {
 		switch a.menu.settingsState {
 		case "THEME":
-			a.menu.settingsState = "MAX_SCROLLOFF"
+			a.menu.settingsState = "TIMER"
 		case "MAX_SCROLLOFF":
 			a.menu.settingsState = "THEME"
+		case "TRASH_RETENTION_DAYS":
+			a.menu.settingsState = "MAX_SCROLLOFF"
+		case "COLOR_MODE":
+			a.menu.settingsState = "TRASH_RETENTION_DAYS"
+		case "DISTINCT_CELLS":
+			a.menu.settingsState = "COLOR_MODE"
+		case "GLYPHS":
+			a.menu.settingsState = "DISTINCT_CELLS"
+		case "CELL_WIDTH":
+			a.menu.settingsState = "GLYPHS"
+		case "GRID":
+			a.menu.settingsState = "CELL_WIDTH"
+		case "STATUS_LINE":
+			a.menu.settingsState = "GRID"
+		case "TIMER":
+			a.menu.settingsState = "STATUS_LINE"
 		}
 	}
 
 	if rune == 'h' || key == tcell.KeyLeft {
 		switch a.menu.settingsState {
 		case "THEME":
-			switch a.menu.settingsThemeState {
-			case "DEFAULT":
-				a.menu.settingsThemeState = "MONO"
-			case "LIGHT":
-				a.menu.settingsThemeState = "DEFAULT"
-			case "DARK":
-				a.menu.settingsThemeState = "LIGHT"
-			case "MONO":
-				a.menu.settingsThemeState = "DARK"
-			}
+			a.menu.settingsThemeState = a.cycleTheme(a.menu.settingsThemeState, -1)
 		case "MAX_SCROLLOFF":
 			if a.menu.settingsMaxScrolloff > 0 {
 				a.menu.settingsMaxScrolloff--
 			}
+		case "TRASH_RETENTION_DAYS":
+			if a.menu.settingsTrashRetentionDays > 0 {
+				a.menu.settingsTrashRetentionDays--
+			}
+		case "COLOR_MODE":
+			a.menu.settingsColorMode = cycleString(settingsColorModes, a.menu.settingsColorMode, -1)
+		case "DISTINCT_CELLS":
+			a.menu.settingsDistinctCells = !a.menu.settingsDistinctCells
+		case "GLYPHS":
+			a.menu.settingsGlyphs = cycleString(settingsGlyphSets, a.menu.settingsGlyphs, -1)
+		case "CELL_WIDTH":
+			if a.menu.settingsCellWidth > 1 {
+				a.menu.settingsCellWidth--
+			}
+		case "GRID":
+			a.menu.settingsGrid = cycleString(settingsGrids, a.menu.settingsGrid, -1)
+		case "STATUS_LINE":
+			a.menu.settingsStatusLineCurr = max(a.menu.settingsStatusLineCurr-1, 0)
+		case "TIMER":
+			a.menu.settingsTimerDecimals = max(a.menu.settingsTimerDecimals-1, 0)
 		}
 	}
 
 	if rune == 'l' || key == tcell.KeyRight {
 		switch a.menu.settingsState {
 		case "THEME":
-			switch a.menu.settingsThemeState {
-			case "DEFAULT":
-				a.menu.settingsThemeState = "LIGHT"
-			case "LIGHT":
-				a.menu.settingsThemeState = "DARK"
-			case "DARK":
-				a.menu.settingsThemeState = "MONO"
-			case "MONO":
-				a.menu.settingsThemeState = "DEFAULT"
-			}
+			a.menu.settingsThemeState = a.cycleTheme(a.menu.settingsThemeState, 1)
 		case "MAX_SCROLLOFF":
 			a.menu.settingsMaxScrolloff++
+		case "TRASH_RETENTION_DAYS":
+			a.menu.settingsTrashRetentionDays++
+		case "COLOR_MODE":
+			a.menu.settingsColorMode = cycleString(settingsColorModes, a.menu.settingsColorMode, 1)
+		case "DISTINCT_CELLS":
+			a.menu.settingsDistinctCells = !a.menu.settingsDistinctCells
+		case "GLYPHS":
+			a.menu.settingsGlyphs = cycleString(settingsGlyphSets, a.menu.settingsGlyphs, 1)
+		case "CELL_WIDTH":
+			if a.menu.settingsCellWidth < len(settingsCellWidths) {
+				a.menu.settingsCellWidth++
+			}
+		case "GRID":
+			a.menu.settingsGrid = cycleString(settingsGrids, a.menu.settingsGrid, 1)
+		case "STATUS_LINE":
+			a.menu.settingsStatusLineCurr = min(a.menu.settingsStatusLineCurr+1, len(statusSegments)-1)
+		case "TIMER":
+			a.menu.settingsTimerDecimals = min(a.menu.settingsTimerDecimals+1, len(settingsTimerDecimals)-1)
 		}
 	}
+
+	if a.menu.settingsState == "STATUS_LINE" {
+		a.eventKeyMenuSettingsStatusLine(rune)
+	}
 }
 
 func isValidPlay(width, height, mineCount int) bool {
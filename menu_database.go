@@ -0,0 +1,348 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/google/uuid"
+	"github.com/mattn/go-runewidth"
+)
+
+func (a *app) backupDatabase() (string, error) {
+	a.wg.Add(1)
+	defer a.wg.Done()
+
+	return a.store.backup()
+}
+
+// the restored database has its own settings, which take effect right away
+func (a *app) restoreDatabase(path string) (string, error) {
+	a.wg.Add(1)
+	defer a.wg.Done()
+
+	backupPath, err := a.store.restore(path)
+	if err != nil {
+		return "", err
+	}
+
+	err = a.store.initSettings()
+	if err != nil {
+		return "", err
+	}
+
+	sett, err := a.store.getSettings()
+	if err != nil {
+		return "", err
+	}
+
+	a.settings = sett
+	a.menu.settingsThemeState = sett.Theme
+	a.menu.settingsMaxScrolloff = sett.MaxScrolloff
+	a.menu.settingsTrashRetentionDays = sett.TrashRetentionDays
+	a.menu.settingsColorMode = sett.ColorMode
+	a.menu.settingsDistinctCells = sett.DistinctCells
+	a.menu.settingsGlyphs = sett.Glyphs
+	a.menu.settingsCellWidth = sett.CellWidth
+	a.menu.settingsGrid = sett.Grid
+	a.menu.settingsStatusLine = slices.Clone(sett.StatusLine)
+	a.menu.settingsStatusLineCurr = 0
+	a.menu.settingsTimerDecimals = sett.TimerDecimals
+	a.reloadThemes()
+	a.setTheme(sett.Theme)
+
+	return backupPath, nil
+}
+
+// what the menu loaded from the replaced database, a pending bulk delete
+// and marks are of its games and are dropped
+func (a *app) reloadDatabaseMenu() error {
+	if a.menu.savedGamesFindBulkState == "PENDING" {
+		close(a.menu.savedGamesFindDeleteUndo)
+	}
+	a.menu.savedGamesFindBulkState = "NONE"
+	a.menu.savedGamesFindDeletePending = []uuid.UUID{}
+	a.menu.savedGamesFindMarked = map[uuid.UUID]bool{}
+	a.menu.savedGamesFindVisualStart = -1
+
+	err := a.reloadSavedGamesTags()
+	if err != nil {
+		return err
+	}
+
+	err = a.reloadSavedGames()
+	if err != nil {
+		return err
+	}
+	a.alignSavedGamesFind()
+
+	return a.reloadTrash()
+}
+
+func (a *app) compactDatabase() (int64, int64, error) {
+	a.wg.Add(1)
+	defer a.wg.Done()
+
+	return a.store.compact()
+}
+
+func (a *app) importDatabase(path string) (importReport, error) {
+	a.wg.Add(1)
+	defer a.wg.Done()
+
+	return a.store.importGames(path)
+}
+
+func (a *app) alignDatabaseBackups() {
+	_, screenHeight := a.screen.Size()
+	listHeight := max(screenHeight-2, 1)
+
+	a.menu.databaseBackupsCurr = max(min(a.menu.databaseBackupsCurr, len(a.menu.databaseBackups)-1), 0)
+
+	if a.menu.databaseBackupsCurr < a.menu.databaseBackupsScreenOffset {
+		a.menu.databaseBackupsScreenOffset = a.menu.databaseBackupsCurr
+	}
+	if a.menu.databaseBackupsCurr >= a.menu.databaseBackupsScreenOffset+listHeight {
+		a.menu.databaseBackupsScreenOffset = a.menu.databaseBackupsCurr - listHeight + 1
+	}
+	a.menu.databaseBackupsScreenOffset = max(min(a.menu.databaseBackupsScreenOffset, len(a.menu.databaseBackups)-listHeight), 0)
+}
+
+func (a *app) drawMenuDatabase() {
+	if a.menu.databaseRestoreState != "NONE" {
+		a.drawMenuDatabaseRestore()
+		return
+	}
+
+	a.setContentString(0, 0, a.defStyle, "Database")
+	a.setContentString(0, 1, a.defStyle, "Backup")
+	a.setContentString(0, 2, a.defStyle, "Restore")
+	a.setContentString(0, 3, a.defStyle, "Compact")
+	a.setContentString(0, 4, a.defStyle, "Import")
+
+	switch a.menu.databaseState {
+	case "BACKUP":
+		a.setContentString(0, 1, a.defStyle.Reverse(true), "Backup")
+	case "RESTORE":
+		a.setContentString(0, 2, a.defStyle.Reverse(true), "Restore")
+	case "COMPACT":
+		a.setContentString(0, 3, a.defStyle.Reverse(true), "Compact")
+	case "IMPORT":
+		a.setContentString(0, 4, a.defStyle.Reverse(true), "Import")
+	}
+
+	if a.menu.databaseImportActive {
+		str := "Import from:" + a.menu.databaseImportPath
+		a.setContentString(0, 6, a.defStyle, str)
+		a.screen.SetContent(runewidth.StringWidth(str), 6, ' ', nil, a.defStyle.Reverse(true))
+		return
+	}
+
+	a.setContentString(0, 6, a.defStyle, a.menu.databaseMessage)
+}
+
+func (a *app) drawMenuDatabaseRestore() {
+	a.setContentString(0, 0, a.defStyle, "Restore from")
+	if len(a.menu.databaseBackups) == 0 {
+		a.setContentString(0, 1, a.defStyle, "No backups")
+		return
+	}
+
+	_, screenHeight := a.screen.Size()
+	for i := range screenHeight - 2 {
+		idx := i + a.menu.databaseBackupsScreenOffset
+		if idx >= len(a.menu.databaseBackups) {
+			break
+		}
+
+		str := filepath.Base(a.menu.databaseBackups[idx])
+		if idx == a.menu.databaseBackupsCurr {
+			a.setContentString(0, i+1, a.defStyle.Reverse(true), str)
+		} else {
+			a.setContentString(0, i+1, a.defStyle, str)
+		}
+	}
+
+	if a.menu.databaseRestoreState == "CONFIRM" {
+		str := "Replace the database with " + filepath.Base(a.menu.databaseBackups[a.menu.databaseBackupsCurr]) + "? y/n"
+		a.setContentString(0, screenHeight-1, a.defStyle, str)
+	}
+}
+
+func (a *app) eventKeyMenuDatabase(key tcell.Key, rune rune) {
+	if a.menu.databaseRestoreState != "NONE" {
+		a.eventKeyMenuDatabaseRestore(key, rune)
+		return
+	}
+
+	if key == tcell.KeyEnter || key == tcell.KeyTab || rune == ' ' || rune == 'd' {
+		switch a.menu.databaseState {
+		case "BACKUP":
+			path, err := a.backupDatabase()
+			if err != nil {
+				a.log(err)
+				a.menu.databaseMessage = "Backup failed"
+				return
+			}
+			a.menu.databaseMessage = "Backed up to " + filepath.Base(path)
+		case "RESTORE":
+			backups, err := listBackups()
+			if err != nil {
+				a.log(err)
+				a.menu.databaseMessage = "Listing backups failed"
+				return
+			}
+			a.menu.databaseBackups = backups
+			a.menu.databaseBackupsCurr = 0
+			a.menu.databaseBackupsScreenOffset = 0
+			a.menu.databaseRestoreState = "LIST"
+		case "COMPACT":
+			before, after, err := a.compactDatabase()
+			if errors.Is(err, errDatabaseClosed) {
+				a.log(err)
+				a.cancel()
+				return
+			}
+			if err != nil {
+				a.log(err)
+				a.menu.databaseMessage = "Compaction failed"
+				return
+			}
+			a.menu.databaseMessage = "Compacted from " + sizeStr(before) + " to " + sizeStr(after)
+		case "IMPORT":
+			a.menu.databaseImportActive = true
+			a.menu.databaseImportPath = ""
+		}
+		return
+	}
+
+	if rune == 'b' {
+		a.menu.databaseMessage = ""
+		a.menu.menuState = "SELECT"
+		return
+	}
+
+	if rune == 'j' || key == tcell.KeyDown {
+		switch a.menu.databaseState {
+		case "BACKUP":
+			a.menu.databaseState = "RESTORE"
+		case "RESTORE":
+			a.menu.databaseState = "COMPACT"
+		case "COMPACT":
+			a.menu.databaseState = "IMPORT"
+		case "IMPORT":
+			a.menu.databaseState = "BACKUP"
+		}
+	}
+
+	if rune == 'k' || key == tcell.KeyUp {
+		switch a.menu.databaseState {
+		case "BACKUP":
+			a.menu.databaseState = "IMPORT"
+		case "RESTORE":
+			a.menu.databaseState = "BACKUP"
+		case "COMPACT":
+			a.menu.databaseState = "RESTORE"
+		case "IMPORT":
+			a.menu.databaseState = "COMPACT"
+		}
+	}
+}
+
+func (a *app) eventKeyMenuDatabaseRestore(key tcell.Key, rune rune) {
+	if a.menu.databaseRestoreState == "CONFIRM" {
+		if rune != 'y' {
+			a.menu.databaseRestoreState = "LIST"
+			return
+		}
+
+		backupPath, err := a.restoreDatabase(a.menu.databaseBackups[a.menu.databaseBackupsCurr])
+		a.menu.databaseRestoreState = "NONE"
+		if errors.Is(err, errDatabaseClosed) {
+			a.log(err)
+			a.cancel()
+			return
+		}
+		if err != nil {
+			a.log(err)
+			a.menu.databaseMessage = "Restore failed: " + err.Error()
+			return
+		}
+		a.menu.databaseMessage = "Restored, the replaced database is " + filepath.Base(backupPath)
+
+		err = a.reloadDatabaseMenu()
+		if err != nil {
+			a.log(err)
+			a.cancel()
+			return
+		}
+
+		a.screen.Clear()
+		a.draw()
+		a.screen.Sync()
+		return
+	}
+
+	if rune == 'b' {
+		a.menu.databaseRestoreState = "NONE"
+		return
+	}
+
+	if len(a.menu.databaseBackups) == 0 {
+		return
+	}
+
+	if key == tcell.KeyEnter || key == tcell.KeyTab || rune == ' ' || rune == 'd' {
+		a.menu.databaseRestoreState = "CONFIRM"
+	}
+
+	if rune == 'j' || key == tcell.KeyDown {
+		if a.menu.databaseBackupsCurr == len(a.menu.databaseBackups)-1 {
+			a.menu.databaseBackupsCurr = 0
+		} else {
+			a.menu.databaseBackupsCurr++
+		}
+		a.alignDatabaseBackups()
+	}
+
+	if rune == 'k' || key == tcell.KeyUp {
+		if a.menu.databaseBackupsCurr == 0 {
+			a.menu.databaseBackupsCurr = len(a.menu.databaseBackups) - 1
+		} else {
+			a.menu.databaseBackupsCurr--
+		}
+		a.alignDatabaseBackups()
+	}
+}
+
+// typing the path of the database to import, ~ is the home directory
+func (a *app) eventKeyMenuDatabaseImport(key tcell.Key, rune rune) {
+	switch key {
+	case tcell.KeyEnter:
+		a.menu.databaseImportActive = false
+
+		path := a.menu.databaseImportPath
+		if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(path, "~/") {
+			path = filepath.Join(home, path[2:])
+		}
+
+		report, err := a.importDatabase(path)
+		if err != nil {
+			a.log(err)
+			a.menu.databaseMessage = "Import failed: " + err.Error()
+			return
+		}
+		a.menu.databaseMessage = importReportStr(report)
+	case tcell.KeyEscape:
+		a.menu.databaseImportActive = false
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		_, size := utf8.DecodeLastRuneInString(a.menu.databaseImportPath)
+		a.menu.databaseImportPath = a.menu.databaseImportPath[:len(a.menu.databaseImportPath)-size]
+	case tcell.KeyRune:
+		a.menu.databaseImportPath += string(rune)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/google/uuid"
+)
+
+// how long a confirmed bulk delete waits for undo before it is committed
+const SAVED_GAMES_DELETE_UNDO_TIME time.Duration = 5 * time.Second
+
+// marked by x, a and v, or inside the range v is selecting right now
+func (a *app) isSavedGamesFindRowMarked(row int) bool {
+	if a.menu.savedGamesFindVisualStart >= 0 &&
+		row >= min(a.menu.savedGamesFindVisualStart, a.menu.savedGamesFindCurr) &&
+		row <= max(a.menu.savedGamesFindVisualStart, a.menu.savedGamesFindCurr) {
+		return true
+	}
+
+	return a.menu.savedGamesFindMarked[a.menu.savedGameIds[a.savedGamesFindIdx(row)]]
+}
+
+// x, v, a, D and the y and u answering D
+func (a *app) eventKeyMenuSavedGamesFindBulk(key tcell.Key, rune rune) {
+	if a.menu.savedGamesFindBulkState == "CONFIRM" {
+		if rune == 'y' {
+			a.startSavedGamesBulkDelete()
+		} else {
+			a.menu.savedGamesFindBulkState = "NONE"
+		}
+		return
+	}
+
+	if a.menu.savedGamesFindBulkState == "PENDING" && rune == 'u' {
+		close(a.menu.savedGamesFindDeleteUndo)
+		a.menu.savedGamesFindDeletePending = []uuid.UUID{}
+		a.menu.savedGamesFindBulkState = "NONE"
+		return
+	}
+
+	if a.savedGamesFindLen() == 0 {
+		return
+	}
+
+	switch rune {
+	case 'x':
+		id := a.menu.savedGameIds[a.savedGamesFindIdx(a.menu.savedGamesFindCurr)]
+		if a.menu.savedGamesFindMarked[id] {
+			delete(a.menu.savedGamesFindMarked, id)
+		} else {
+			a.menu.savedGamesFindMarked[id] = true
+		}
+	case 'v':
+		if a.menu.savedGamesFindVisualStart < 0 {
+			a.menu.savedGamesFindVisualStart = a.menu.savedGamesFindCurr
+			return
+		}
+
+		start := min(a.menu.savedGamesFindVisualStart, a.menu.savedGamesFindCurr)
+		end := max(a.menu.savedGamesFindVisualStart, a.menu.savedGamesFindCurr)
+		for row := start; row <= end; row++ {
+			a.menu.savedGamesFindMarked[a.menu.savedGameIds[a.savedGamesFindIdx(row)]] = true
+		}
+		a.menu.savedGamesFindVisualStart = -1
+	case 'a':
+		// every row of the list, so what the filters and search matched, or none when all are marked already
+		allMarked := true
+		for row := range a.savedGamesFindLen() {
+			if !a.menu.savedGamesFindMarked[a.menu.savedGameIds[a.savedGamesFindIdx(row)]] {
+				allMarked = false
+				break
+			}
+		}
+
+		for row := range a.savedGamesFindLen() {
+			id := a.menu.savedGameIds[a.savedGamesFindIdx(row)]
+			if allMarked {
+				delete(a.menu.savedGamesFindMarked, id)
+			} else {
+				a.menu.savedGamesFindMarked[id] = true
+			}
+		}
+	case 'D':
+		if a.menu.savedGamesFindBulkState == "NONE" && len(a.menu.savedGamesFindMarked) > 0 {
+			a.menu.savedGamesFindBulkState = "CONFIRM"
+		}
+	}
+}
+
+// the event loop commits the delete when it is sent on savedGamesDeleteDue
+// after the undo time, interrupts before then redraw the countdown
+func (a *app) startSavedGamesBulkDelete() {
+	a.menu.savedGamesFindDeletePending = []uuid.UUID{}
+	for id := range a.menu.savedGamesFindMarked {
+		a.menu.savedGamesFindDeletePending = append(a.menu.savedGamesFindDeletePending, id)
+	}
+	a.menu.savedGamesFindDeleteAt = time.Now().Add(SAVED_GAMES_DELETE_UNDO_TIME)
+	a.menu.savedGamesFindBulkState = "PENDING"
+
+	ticker := time.NewTicker(time.Second / 4)
+	undo := make(chan struct{})
+	a.menu.savedGamesFindDeleteUndo = undo
+	deleteAt := a.menu.savedGamesFindDeleteAt
+
+	safeGo(func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-undo:
+				return
+			case <-ticker.C:
+				if time.Now().After(deleteAt) {
+					select {
+					case a.savedGamesDeleteDue <- struct{}{}:
+					case <-undo:
+					case <-a.ctx.Done():
+					}
+					return
+				}
+
+				// a dropped one only skips a second of the countdown
+				a.screen.PostEvent(tcell.NewEventInterrupt(nil))
+			}
+		}
+	}, a.screen)
+}
+
+// quitting during the undo time deletes the games right away instead of
+// dropping the confirmed delete
+func (a *app) flushSavedGamesBulkDelete() {
+	if a.menu.savedGamesFindBulkState != "PENDING" {
+		return
+	}
+
+	a.menu.savedGamesFindDeleteAt = time.Now()
+	a.commitSavedGamesBulkDelete()
+}
+
+func (a *app) commitSavedGamesBulkDelete() {
+	if a.menu.savedGamesFindBulkState != "PENDING" || time.Now().Before(a.menu.savedGamesFindDeleteAt) {
+		return
+	}
+
+	err := a.deleteGames(a.menu.savedGamesFindDeletePending)
+	if err != nil {
+		a.log(err)
+		a.cancel()
+		return
+	}
+
+	for _, id := range a.menu.savedGamesFindDeletePending {
+		delete(a.menu.savedGamesFindMarked, id)
+	}
+	a.menu.savedGamesFindDeletePending = []uuid.UUID{}
+	a.menu.savedGamesFindBulkState = "NONE"
+
+	if a.menu.menuState == "SAVED_GAMES" && a.menu.savedGamesState == "FIND" {
+		err = a.reloadSavedGames()
+		if err != nil {
+			a.log(err)
+			a.cancel()
+			return
+		}
+		a.alignSavedGamesFind()
+	}
+}
+
+// the only screen showing marks and the bulk delete countdown
+func (a *app) savedGamesFindVisible() bool {
+	return a.state == "MENU" && a.menu.menuState == "SAVED_GAMES" && a.menu.savedGamesState == "FIND"
+}
+
+// what the footer says about marks and a bulk delete in progress
+func (a *app) savedGamesFindBulkStr() string {
+	switch a.menu.savedGamesFindBulkState {
+	case "CONFIRM":
+		return "Delete " + strconv.Itoa(len(a.menu.savedGamesFindMarked)) + " games? y/n"
+	case "PENDING":
+		secondsLeft := int(time.Until(a.menu.savedGamesFindDeleteAt).Seconds()) + 1
+		return "Deleting " + strconv.Itoa(len(a.menu.savedGamesFindDeletePending)) + " games in " + strconv.Itoa(max(secondsLeft, 0)) + "s, u to undo"
+	}
+
+	str := ""
+	if len(a.menu.savedGamesFindMarked) > 0 {
+		str += strconv.Itoa(len(a.menu.savedGamesFindMarked)) + " marked"
+	}
+	if a.menu.savedGamesFindVisualStart >= 0 {
+		if str != "" {
+			str += " "
+		}
+		str += "VISUAL"
+	}
+
+	return str
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// custom dates are typed as YYYYMMDD
+const SAVED_GAMES_DATE_LAYOUT string = "20060102"
+
+type savedGamesPrepareRow struct {
+	state       string
+	customState string
+}
+
+// rows j and k move between, every custom input is a row of its own
+func (a *app) savedGamesPrepareRows() []savedGamesPrepareRow {
+	rows := []savedGamesPrepareRow{{state: "SORT_BY"}}
+
+	if a.menu.savedGamesPrepareFieldState == "CUSTOM" {
+		rows = append(rows,
+			savedGamesPrepareRow{state: "FIELD", customState: "WIDTH"},
+			savedGamesPrepareRow{state: "FIELD", customState: "HEIGHT"},
+			savedGamesPrepareRow{state: "FIELD", customState: "MINE_COUNT"},
+		)
+	} else {
+		rows = append(rows, savedGamesPrepareRow{state: "FIELD"})
+	}
+
+	rows = append(rows, savedGamesPrepareRow{state: "RESULT"})
+
+	if a.menu.savedGamesPrepareDateState == "CUSTOM" {
+		rows = append(rows,
+			savedGamesPrepareRow{state: "DATE", customState: "FROM"},
+			savedGamesPrepareRow{state: "DATE", customState: "TO"},
+		)
+	} else {
+		rows = append(rows, savedGamesPrepareRow{state: "DATE"})
+	}
+
+	return append(rows,
+		savedGamesPrepareRow{state: "DURATION"},
+		savedGamesPrepareRow{state: "DENSITY"},
+		savedGamesPrepareRow{state: "SIZE"},
+		savedGamesPrepareRow{state: "STARRED"},
+		savedGamesPrepareRow{state: "TAG"},
+	)
+}
+
+func (a *app) moveSavedGamesPrepareRow(direction int) {
+	rows := a.savedGamesPrepareRows()
+
+	curr := 0
+	for i, row := range rows {
+		if row.state == a.menu.savedGamesPrepareState && row.customState == a.savedGamesPrepareCustomState(row.state) {
+			curr = i
+			break
+		}
+	}
+
+	next := rows[(curr+direction+len(rows))%len(rows)]
+	a.menu.savedGamesPrepareState = next.state
+	switch next.state {
+	case "FIELD":
+		if next.customState != "" {
+			a.menu.savedGamesPrepareFieldCustomState = next.customState
+		}
+	case "DATE":
+		if next.customState != "" {
+			a.menu.savedGamesPrepareDateCustomState = next.customState
+		}
+	}
+}
+
+// custom input focused in a row with custom inputs, empty when the row has none
+func (a *app) savedGamesPrepareCustomState(state string) string {
+	switch {
+	case state == "FIELD" && a.menu.savedGamesPrepareFieldState == "CUSTOM":
+		return a.menu.savedGamesPrepareFieldCustomState
+	case state == "DATE" && a.menu.savedGamesPrepareDateState == "CUSTOM":
+		return a.menu.savedGamesPrepareDateCustomState
+	}
+
+	return ""
+}
+
+func cycleString(options []string, curr string, direction int) string {
+	for i, option := range options {
+		if option == curr {
+			return options[(i+direction+len(options))%len(options)]
+		}
+	}
+
+	return options[0]
+}
+
+// h and l on the filter rows
+func (a *app) cycleSavedGamesPrepareFilter(direction int) {
+	switch a.menu.savedGamesPrepareState {
+	case "RESULT":
+		a.menu.savedGamesPrepareResultState = cycleString([]string{"ALL", "WON", "LOST"}, a.menu.savedGamesPrepareResultState, direction)
+	case "DATE":
+		a.menu.savedGamesPrepareDateState = cycleString([]string{"ALL", "TODAY", "WEEK", "CUSTOM"}, a.menu.savedGamesPrepareDateState, direction)
+		a.menu.savedGamesPrepareDateCustomState = "FROM"
+	case "DURATION":
+		a.menu.savedGamesPrepareDurationState = cycleString([]string{"MIN", "MAX"}, a.menu.savedGamesPrepareDurationState, direction)
+	case "DENSITY":
+		a.menu.savedGamesPrepareDensityState = cycleString([]string{"MIN", "MAX"}, a.menu.savedGamesPrepareDensityState, direction)
+	case "SIZE":
+		a.menu.savedGamesPrepareSizeState = cycleString([]string{"WIDTH_MIN", "WIDTH_MAX", "HEIGHT_MIN", "HEIGHT_MAX"}, a.menu.savedGamesPrepareSizeState, direction)
+	case "STARRED":
+		a.menu.savedGamesPrepareStarredState = cycleString([]string{"ALL", "STARRED"}, a.menu.savedGamesPrepareStarredState, direction)
+	case "TAG":
+		a.menu.savedGamesPrepareTag = cycleString(append([]string{""}, a.menu.savedGamesPrepareTags...), a.menu.savedGamesPrepareTag, direction)
+	}
+}
+
+// the filter value digits and backspace edit, nil when no filter input is focused
+func (a *app) savedGamesPrepareFilterInput() *int {
+	switch a.menu.savedGamesPrepareState {
+	case "DATE":
+		if a.menu.savedGamesPrepareDateState != "CUSTOM" {
+			return nil
+		}
+		if a.menu.savedGamesPrepareDateCustomState == "FROM" {
+			return &a.menu.savedGamesPrepareDateCustomFrom
+		}
+		return &a.menu.savedGamesPrepareDateCustomTo
+	case "DURATION":
+		if a.menu.savedGamesPrepareDurationState == "MIN" {
+			return &a.menu.savedGamesPrepareDurationMin
+		}
+		return &a.menu.savedGamesPrepareDurationMax
+	case "DENSITY":
+		if a.menu.savedGamesPrepareDensityState == "MIN" {
+			return &a.menu.savedGamesPrepareDensityMin
+		}
+		return &a.menu.savedGamesPrepareDensityMax
+	case "SIZE":
+		switch a.menu.savedGamesPrepareSizeState {
+		case "WIDTH_MIN":
+			return &a.menu.savedGamesPrepareSizeWidthMin
+		case "WIDTH_MAX":
+			return &a.menu.savedGamesPrepareSizeWidthMax
+		case "HEIGHT_MIN":
+			return &a.menu.savedGamesPrepareSizeHeightMin
+		case "HEIGHT_MAX":
+			return &a.menu.savedGamesPrepareSizeHeightMax
+		}
+	}
+
+	return nil
+}
+
+func (a *app) eventKeyMenuSavedGamesPrepareFilters(key tcell.Key, rune rune) {
+	input := a.savedGamesPrepareFilterInput()
+	if input == nil {
+		return
+	}
+
+	if key == tcell.KeyBackspace || key == tcell.KeyBackspace2 {
+		*input = intRemoveLast(*input)
+	}
+
+	switch rune {
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		// dates have exactly 8 digits, other inputs are kept to sane lengths as well
+		if len(strconv.Itoa(*input)) < len(SAVED_GAMES_DATE_LAYOUT) {
+			*input = intToIntAdd(*input, rune)
+		}
+	}
+}
+
+// custom dates have to be full dates, focuses the first invalid one
+func (a *app) validSavedGamesPrepareDates() bool {
+	if a.menu.savedGamesPrepareDateState != "CUSTOM" {
+		return true
+	}
+
+	if _, ok := parseSavedGamesDate(a.menu.savedGamesPrepareDateCustomFrom); !ok {
+		a.menu.savedGamesPrepareState = "DATE"
+		a.menu.savedGamesPrepareDateCustomState = "FROM"
+		return false
+	}
+	if _, ok := parseSavedGamesDate(a.menu.savedGamesPrepareDateCustomTo); !ok {
+		a.menu.savedGamesPrepareState = "DATE"
+		a.menu.savedGamesPrepareDateCustomState = "TO"
+		return false
+	}
+
+	return true
+}
+
+// a min above its max would match no game, focuses the first such min
+func (a *app) validSavedGamesPrepareBounds() bool {
+	bounds := []struct {
+		state    string
+		minState string
+		min      int
+		max      int
+	}{
+		{"DURATION", "MIN", a.menu.savedGamesPrepareDurationMin, a.menu.savedGamesPrepareDurationMax},
+		{"DENSITY", "MIN", a.menu.savedGamesPrepareDensityMin, a.menu.savedGamesPrepareDensityMax},
+		{"SIZE", "WIDTH_MIN", a.menu.savedGamesPrepareSizeWidthMin, a.menu.savedGamesPrepareSizeWidthMax},
+		{"SIZE", "HEIGHT_MIN", a.menu.savedGamesPrepareSizeHeightMin, a.menu.savedGamesPrepareSizeHeightMax},
+	}
+
+	for _, b := range bounds {
+		// 0 is unbounded
+		if b.min == 0 || b.max == 0 || b.min <= b.max {
+			continue
+		}
+
+		a.menu.savedGamesPrepareState = b.state
+		switch b.state {
+		case "DURATION":
+			a.menu.savedGamesPrepareDurationState = b.minState
+		case "DENSITY":
+			a.menu.savedGamesPrepareDensityState = b.minState
+		case "SIZE":
+			a.menu.savedGamesPrepareSizeState = b.minState
+		}
+		a.menu.savedGamesPrepareMessage = "Min " + strconv.Itoa(b.min) + " is above max " + strconv.Itoa(b.max)
+		return false
+	}
+
+	return true
+}
+
+// 0 is a valid unbounded date and gives zero time
+func parseSavedGamesDate(date int) (time.Time, bool) {
+	if date == 0 {
+		return time.Time{}, true
+	}
+
+	t, err := time.ParseInLocation(SAVED_GAMES_DATE_LAYOUT, strconv.Itoa(date), time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// created at range of the date filter, weeks start on monday
+func (a *app) savedGamesCreatedRange(now time.Time) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch a.menu.savedGamesPrepareDateState {
+	case "TODAY":
+		return today, time.Time{}
+	case "WEEK":
+		return today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7)), time.Time{}
+	case "CUSTOM":
+		from, _ := parseSavedGamesDate(a.menu.savedGamesPrepareDateCustomFrom)
+		to, _ := parseSavedGamesDate(a.menu.savedGamesPrepareDateCustomTo)
+		if !to.IsZero() {
+			// the to date is included
+			to = to.AddDate(0, 0, 1)
+		}
+		return from, to
+	}
+
+	return time.Time{}, time.Time{}
+}
+
+// draws filter rows starting at y
+func (a *app) drawMenuSavedGamesPrepareFilters(y int) {
+	// RESULT
+	a.drawMenuLabel(y, "Result:", a.menu.savedGamesPrepareState == "RESULT")
+	a.drawMenuOptions(y+1, []string{"All", "Won", "Lost"}, []string{"ALL", "WON", "LOST"}, a.menu.savedGamesPrepareResultState)
+	y += 2
+
+	// DATE
+	a.drawMenuLabel(y, "Date:", a.menu.savedGamesPrepareState == "DATE")
+	a.drawMenuOptions(y+1, []string{"All", "Today", "Week", "Custom"}, []string{"ALL", "TODAY", "WEEK", "CUSTOM"}, a.menu.savedGamesPrepareDateState)
+	y += 2
+	if a.menu.savedGamesPrepareDateState == "CUSTOM" {
+		dateFocused := a.menu.savedGamesPrepareState == "DATE"
+		a.drawMenuInput(0, y, "From(YYYYMMDD):", a.menu.savedGamesPrepareDateCustomFrom, dateFocused && a.menu.savedGamesPrepareDateCustomState == "FROM")
+		a.drawMenuInput(0, y+1, "To(YYYYMMDD):", a.menu.savedGamesPrepareDateCustomTo, dateFocused && a.menu.savedGamesPrepareDateCustomState == "TO")
+		y += 2
+	}
+
+	// DURATION
+	durationFocused := a.menu.savedGamesPrepareState == "DURATION"
+	a.drawMenuLabel(y, "Duration(s):", durationFocused)
+	currStart := a.drawMenuInput(0, y+1, "Min:", a.menu.savedGamesPrepareDurationMin, durationFocused && a.menu.savedGamesPrepareDurationState == "MIN")
+	a.drawMenuInput(currStart, y+1, "Max:", a.menu.savedGamesPrepareDurationMax, durationFocused && a.menu.savedGamesPrepareDurationState == "MAX")
+	y += 2
+
+	// DENSITY
+	densityFocused := a.menu.savedGamesPrepareState == "DENSITY"
+	a.drawMenuLabel(y, "Mine Density(%):", densityFocused)
+	currStart = a.drawMenuInput(0, y+1, "Min:", a.menu.savedGamesPrepareDensityMin, densityFocused && a.menu.savedGamesPrepareDensityState == "MIN")
+	a.drawMenuInput(currStart, y+1, "Max:", a.menu.savedGamesPrepareDensityMax, densityFocused && a.menu.savedGamesPrepareDensityState == "MAX")
+	y += 2
+
+	// SIZE
+	sizeFocused := a.menu.savedGamesPrepareState == "SIZE"
+	a.drawMenuLabel(y, "Size:", sizeFocused)
+	currStart = a.drawMenuInput(0, y+1, "Width Min:", a.menu.savedGamesPrepareSizeWidthMin, sizeFocused && a.menu.savedGamesPrepareSizeState == "WIDTH_MIN")
+	currStart = a.drawMenuInput(currStart, y+1, "Max:", a.menu.savedGamesPrepareSizeWidthMax, sizeFocused && a.menu.savedGamesPrepareSizeState == "WIDTH_MAX")
+	currStart = a.drawMenuInput(currStart, y+1, "Height Min:", a.menu.savedGamesPrepareSizeHeightMin, sizeFocused && a.menu.savedGamesPrepareSizeState == "HEIGHT_MIN")
+	a.drawMenuInput(currStart, y+1, "Max:", a.menu.savedGamesPrepareSizeHeightMax, sizeFocused && a.menu.savedGamesPrepareSizeState == "HEIGHT_MAX")
+	y += 2
+
+	// STARRED
+	a.drawMenuLabel(y, "Starred:", a.menu.savedGamesPrepareState == "STARRED")
+	a.drawMenuOptions(y+1, []string{"All", "Starred"}, []string{"ALL", "STARRED"}, a.menu.savedGamesPrepareStarredState)
+	y += 2
+
+	// TAG, only the chosen one is shown since there can be many
+	tagFocused := a.menu.savedGamesPrepareState == "TAG"
+	a.drawMenuLabel(y, "Tag:", tagFocused)
+	tagStr := "All"
+	if a.menu.savedGamesPrepareTag != "" {
+		tagStr = a.menu.savedGamesPrepareTag
+	}
+	if len(a.menu.savedGamesPrepareTags) == 0 {
+		tagStr += " (no tags yet)"
+	}
+	a.setContentString(0, y+1, a.defStyle.Reverse(true), tagStr)
+	y += 2
+
+	if a.menu.savedGamesPrepareMessage != "" {
+		a.setContentString(0, y+1, a.defStyle, a.menu.savedGamesPrepareMessage)
+	}
+}
+
+func (a *app) drawMenuLabel(y int, label string, focused bool) {
+	style := a.defStyle
+	if focused {
+		style = style.Reverse(true)
+	}
+	a.setContentString(0, y, style, label)
+}
+
+// draws option labels in one row, the one whose state is curr reversed
+func (a *app) drawMenuOptions(y int, labels []string, states []string, curr string) {
+	currStart := 0
+	for i, label := range labels {
+		style := a.defStyle
+		if states[i] == curr {
+			style = style.Reverse(true)
+		}
+		a.setContentString(currStart, y, style, label)
+		currStart += len(label) + 1
+	}
+}
+
+// draws label and value with a cursor after it when focused, returns where the next input starts
+func (a *app) drawMenuInput(x, y int, label string, value int, focused bool) int {
+	str := label
+	if value > 0 {
+		str += strconv.Itoa(value)
+	}
+	a.setContentString(x, y, a.defStyle, str)
+
+	if focused {
+		a.screen.SetContent(x+len(str), y, ' ', nil, a.defStyle.Reverse(true))
+	}
+
+	return x + len(str) + 2
+}
+
+// active filters for the FIND header, empty when nothing is filtered
+func (a *app) savedGamesFiltersStr() string {
+	filters := []string{}
+
+	if a.menu.savedGamesPrepareResultState != "ALL" {
+		filters = append(filters, a.menu.savedGamesPrepareResultState)
+	}
+
+	switch a.menu.savedGamesPrepareDateState {
+	case "TODAY", "WEEK":
+		filters = append(filters, a.menu.savedGamesPrepareDateState)
+	case "CUSTOM":
+		filters = append(filters, filterRangeStr(a.menu.savedGamesPrepareDateCustomFrom, a.menu.savedGamesPrepareDateCustomTo, ""))
+	}
+
+	filters = append(filters,
+		filterRangeStr(a.menu.savedGamesPrepareDurationMin, a.menu.savedGamesPrepareDurationMax, "s"),
+		filterRangeStr(a.menu.savedGamesPrepareDensityMin, a.menu.savedGamesPrepareDensityMax, "%"),
+	)
+
+	if widthStr := filterRangeStr(a.menu.savedGamesPrepareSizeWidthMin, a.menu.savedGamesPrepareSizeWidthMax, ""); widthStr != "" {
+		filters = append(filters, "W"+widthStr)
+	}
+	if heightStr := filterRangeStr(a.menu.savedGamesPrepareSizeHeightMin, a.menu.savedGamesPrepareSizeHeightMax, ""); heightStr != "" {
+		filters = append(filters, "H"+heightStr)
+	}
+
+	if a.menu.savedGamesPrepareStarredState == "STARRED" {
+		filters = append(filters, "STARRED")
+	}
+	if a.menu.savedGamesPrepareTag != "" {
+		filters = append(filters, "#"+a.menu.savedGamesPrepareTag)
+	}
+
+	filters = slices.DeleteFunc(filters, func(filter string) bool {
+		return filter == ""
+	})
+
+	return strings.Join(filters, " ")
+}
+
+// 10-60s, 10s- or -60s, empty when both bounds are unset
+func filterRangeStr(rangeMin, rangeMax int, unit string) string {
+	switch {
+	case rangeMin == 0 && rangeMax == 0:
+		return ""
+	case rangeMax == 0:
+		return strconv.Itoa(rangeMin) + unit + "-"
+	case rangeMin == 0:
+		return "-" + strconv.Itoa(rangeMax) + unit
+	}
+
+	return strconv.Itoa(rangeMin) + "-" + strconv.Itoa(rangeMax) + unit
+}
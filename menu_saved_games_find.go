@@ -0,0 +1,241 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// row of the FIND list without its number, the search matches against it
+func savedGameRowStr(info gameInfo) string {
+	width := strconv.Itoa(info.FieldWidth)
+	height := strconv.Itoa(info.FieldHeight)
+	mineCount := strconv.Itoa(info.MineCount)
+	time := formatFinalTime(info.GameDuration)
+
+	date := info.CreatedAt.Format("2006-01-02 15:04:05")
+
+	str := info.Result + " " + width + "x" + height + "(" + mineCount + ")" + " " + time + " " + date
+	if info.Starred {
+		str += " *"
+	}
+	for _, tag := range info.Tags {
+		str += " #" + tag
+	}
+
+	return str
+}
+
+// how many rows the FIND list has, only matches when searching
+func (a *app) savedGamesFindLen() int {
+	if a.menu.savedGamesFindSearch == "" {
+		return len(a.menu.savedGameIds)
+	}
+
+	return len(a.menu.savedGamesFindMatches)
+}
+
+// index into savedGameIds of the row-th row of the FIND list
+func (a *app) savedGamesFindIdx(row int) int {
+	if a.menu.savedGamesFindSearch == "" {
+		return row
+	}
+
+	return a.menu.savedGamesFindMatches[row]
+}
+
+// games a search decodes at most at once, in pages of SAVED_GAMES_PAGE_SIZE,
+// when the games loaded so far don't have enough matches
+const SAVED_GAMES_SEARCH_PAGES int = 4
+
+// Every space separated word of the search has to be in the row, case is
+// ignored. Only the games loaded so far and a few more pages are searched,
+// the rest as the list moves towards the last match.
+func (a *app) searchSavedGames() error {
+	a.menu.savedGamesFindMatches = []int{}
+	a.menu.savedGamesFindSearched = 0
+	if a.menu.savedGamesFindSearch == "" {
+		return nil
+	}
+
+	return a.extendSavedGamesSearch(0)
+}
+
+// searches on until the list has a screen of rows after row, loaded games
+// are searched either way
+func (a *app) extendSavedGamesSearch(row int) error {
+	if a.menu.savedGamesFindSearch == "" {
+		return nil
+	}
+
+	rows := row + a.savedGamesFindListHeight()
+	words := strings.Fields(strings.ToLower(a.menu.savedGamesFindSearch))
+	pages := 0
+	for a.menu.savedGamesFindSearched < len(a.menu.savedGameIds) {
+		idx := a.menu.savedGamesFindSearched
+		info, ok := a.menu.savedGamesCache[a.menu.savedGameIds[idx]]
+		if !ok {
+			if len(a.menu.savedGamesFindMatches) >= rows || pages == SAVED_GAMES_SEARCH_PAGES {
+				return nil
+			}
+			pages++
+
+			var err error
+			info, err = a.savedGameInfo(idx)
+			if err != nil {
+				return err
+			}
+		}
+
+		rowStr := strings.ToLower(savedGameRowStr(info))
+		matches := true
+		for _, word := range words {
+			if !strings.Contains(rowStr, word) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			a.menu.savedGamesFindMatches = append(a.menu.savedGamesFindMatches, idx)
+		}
+		a.menu.savedGamesFindSearched++
+	}
+
+	return nil
+}
+
+// games after the last match may match too
+func (a *app) savedGamesSearchPartial() bool {
+	return a.menu.savedGamesFindSearch != "" && a.menu.savedGamesFindSearched < len(a.menu.savedGameIds)
+}
+
+// rows the list has between the header and the footer
+func (a *app) savedGamesFindListHeight() int {
+	_, screenHeight := a.screen.Size()
+	return max(screenHeight-2, 1)
+}
+
+// keeps the selection in bounds and on screen without moving the list more than needed
+func (a *app) alignSavedGamesFind() {
+	listLen := a.savedGamesFindLen()
+	listHeight := a.savedGamesFindListHeight()
+
+	a.menu.savedGamesFindCurr = max(min(a.menu.savedGamesFindCurr, listLen-1), 0)
+
+	if a.menu.savedGamesFindCurr < a.menu.savedGamesFindScreenOffset {
+		a.menu.savedGamesFindScreenOffset = a.menu.savedGamesFindCurr
+	}
+	if a.menu.savedGamesFindCurr >= a.menu.savedGamesFindScreenOffset+listHeight {
+		a.menu.savedGamesFindScreenOffset = a.menu.savedGamesFindCurr - listHeight + 1
+	}
+
+	// no empty rows at the bottom while there are rows above the screen
+	a.menu.savedGamesFindScreenOffset = min(a.menu.savedGamesFindScreenOffset, max(listLen-listHeight, 0))
+	a.menu.savedGamesFindScreenOffset = max(a.menu.savedGamesFindScreenOffset, 0)
+}
+
+func (a *app) drawMenuSavedGamesFindFooter() {
+	_, screenHeight := a.screen.Size()
+	if screenHeight < 3 {
+		return
+	}
+	y := screenHeight - 1
+
+	listLen := a.savedGamesFindLen()
+	position := 0
+	if listLen > 0 {
+		position = a.menu.savedGamesFindCurr + 1
+	}
+
+	footerStr := strconv.Itoa(position) + "/" + strconv.Itoa(listLen)
+	if a.savedGamesSearchPartial() {
+		footerStr += " in " + strconv.Itoa(a.menu.savedGamesFindSearched)
+	}
+	if a.menu.savedGamesFindSearch != "" || a.menu.savedGamesFindSearchActive {
+		footerStr += " of " + strconv.Itoa(len(a.menu.savedGameIds))
+		footerStr += " /" + a.menu.savedGamesFindSearch
+	}
+	a.setContentString(0, y, a.defStyle, footerStr)
+
+	if a.menu.savedGamesFindSearchActive {
+		a.screen.SetContent(runewidth.StringWidth(footerStr), y, ' ', nil, a.defStyle.Reverse(true))
+	}
+
+	if bulkStr := a.savedGamesFindBulkStr(); bulkStr != "" {
+		a.setContentString(runewidth.StringWidth(footerStr)+2, y, a.defStyle, bulkStr)
+	}
+}
+
+// typing the search, every change filters the list right away
+func (a *app) eventKeyMenuSavedGamesFindSearch(key tcell.Key, rune rune) {
+	switch key {
+	case tcell.KeyEnter:
+		a.menu.savedGamesFindSearchActive = false
+		return
+	case tcell.KeyEscape:
+		a.menu.savedGamesFindSearchActive = false
+		a.menu.savedGamesFindSearch = ""
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if a.menu.savedGamesFindSearch == "" {
+			a.menu.savedGamesFindSearchActive = false
+			return
+		}
+		_, size := utf8.DecodeLastRuneInString(a.menu.savedGamesFindSearch)
+		a.menu.savedGamesFindSearch = a.menu.savedGamesFindSearch[:len(a.menu.savedGamesFindSearch)-size]
+	case tcell.KeyRune:
+		a.menu.savedGamesFindSearch += string(rune)
+	default:
+		return
+	}
+
+	err := a.searchSavedGames()
+	if err != nil {
+		a.log(err)
+		a.cancel()
+		return
+	}
+
+	a.menu.savedGamesFindCurr = 0
+	a.menu.savedGamesFindScreenOffset = 0
+}
+
+// PgUp, PgDn, gg and G
+func (a *app) eventKeyMenuSavedGamesFindJump(key tcell.Key, rune rune) {
+	listHeight := a.savedGamesFindListHeight()
+
+	if key == tcell.KeyPgDn || rune == 'G' {
+		err := a.extendSavedGamesSearch(a.menu.savedGamesFindCurr + listHeight)
+		if err != nil {
+			a.log(err)
+			a.cancel()
+			return
+		}
+	}
+
+	switch {
+	case key == tcell.KeyPgDn:
+		a.menu.savedGamesFindCurr += listHeight
+		a.menu.savedGamesFindScreenOffset += listHeight
+	case key == tcell.KeyPgUp:
+		a.menu.savedGamesFindCurr -= listHeight
+		a.menu.savedGamesFindScreenOffset -= listHeight
+	case rune == 'G':
+		a.menu.savedGamesFindCurr = a.savedGamesFindLen() - 1
+	case rune == 'g':
+		if time.Since(a.menu.savedGamesFindLastGPress).Abs() < time.Second/2 {
+			a.menu.savedGamesFindCurr = 0
+			a.menu.savedGamesFindLastGPress = time.Now().Add(-time.Minute)
+		} else {
+			a.menu.savedGamesFindLastGPress = time.Now()
+		}
+	default:
+		return
+	}
+
+	a.alignSavedGamesFind()
+}
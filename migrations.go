@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type migration struct {
+	// schema version the database has after the migration
+	version     int
+	description string
+	run         func(tx *bolt.Tx) error
+}
+
+// Databases without a SchemaVersion key are version 0, written before
+// records were versioned. Append new migrations at the end.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "wrap records in versioned envelopes",
+		run:         migrateWrapRecords,
+	},
+	{
+		version:     2,
+		description: "build saved games indexes",
+		run:         migrateBuildGameIndexes,
+	},
+	{
+		version:     3,
+		description: "default trash retention in settings",
+		run:         migrateTrashRetention,
+	},
+	{
+		version:     4,
+		description: "rebuild saved games indexes with stars and tags",
+		run:         migrateBuildGameIndexes,
+	},
+	{
+		version:     5,
+		description: "default colour mode in settings",
+		run:         migrateColorMode,
+	},
+	{
+		version:     6,
+		description: "default glyph set in settings",
+		run:         migrateGlyphs,
+	},
+	{
+		version:     7,
+		description: "default cell width and grid in settings",
+		run:         migrateFieldLayout,
+	},
+	{
+		version:     8,
+		description: "default status line in settings",
+		run:         migrateStatusLine,
+	},
+	{
+		version:     9,
+		description: "default timer decimals in settings",
+		run:         migrateTimerDecimals,
+	},
+}
+
+func latestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+func getSchemaVersion(tx *bolt.Tx) int {
+	bucketMeta := tx.Bucket([]byte("Meta"))
+	if bucketMeta == nil {
+		return 0
+	}
+
+	value := bucketMeta.Get([]byte("SchemaVersion"))
+	if len(value) != 8 {
+		return 0
+	}
+
+	return int(binary.BigEndian.Uint64(value))
+}
+
+func putSchemaVersion(tx *bolt.Tx, version int) error {
+	bucketMeta, err := tx.CreateBucketIfNotExists([]byte("Meta"))
+	if err != nil {
+		return err
+	}
+
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(version))
+	return bucketMeta.Put([]byte("SchemaVersion"), value)
+}
+
+// Runs every migration newer than the database's schema version, each in its
+// own transaction. A copy of the database is written to the backups directory
+// before the first one runs.
+func (s *boltStorage) migrate() error {
+	var version int
+	var empty bool
+	err := s.view(func(tx *bolt.Tx) error {
+		version = getSchemaVersion(tx)
+		empty = isEmpty(tx)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if version > latestSchemaVersion() {
+		return fmt.Errorf("database schema version %d is newer than this termines supports (%d)", version, latestSchemaVersion())
+	}
+
+	if empty {
+		return s.update(func(tx *bolt.Tx) error {
+			return putSchemaVersion(tx, latestSchemaVersion())
+		})
+	}
+
+	if version == latestSchemaVersion() {
+		return nil
+	}
+
+	backupsDir, err := getBackupsDir()
+	if err != nil {
+		return err
+	}
+	backupPath := filepath.Join(backupsDir, "data-v"+strconv.Itoa(version)+"-"+time.Now().Format(BACKUP_TIME_LAYOUT)+".db")
+	err = s.backupTo(backupPath)
+	if err != nil {
+		return err
+	}
+
+	err = s.runMigrations(version)
+	if err != nil {
+		return fmt.Errorf("%w, backup is at %s", err, backupPath)
+	}
+
+	return nil
+}
+
+// every migration newer than version, without a backup
+func (s *boltStorage) runMigrations(version int) error {
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+
+		err := s.update(func(tx *bolt.Tx) error {
+			err := m.run(tx)
+			if err != nil {
+				return err
+			}
+
+			return putSchemaVersion(tx, m.version)
+		})
+		if err != nil {
+			return fmt.Errorf("migration to version %d (%s): %w", m.version, m.description, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *boltStorage) backupTo(path string) error {
+	return s.view(func(tx *bolt.Tx) error {
+		return tx.CopyFile(path, 0600)
+	})
+}
+
+func getBackupsDir() (string, error) {
+	terminesDir, err := getTerminesDir()
+	if err != nil {
+		return "", err
+	}
+
+	backupsDir := filepath.Join(terminesDir, "backups")
+
+	err = os.MkdirAll(backupsDir, 0o755)
+	if err != nil {
+		return "", err
+	}
+
+	return backupsDir, nil
+}
+
+// version 0 values are the gob encoding records now carry as payload
+func migrateWrapRecords(tx *bolt.Tx) error {
+	for _, name := range []string{"GameInfo", "GameData", "Settings"} {
+		bucket := tx.Bucket([]byte(name))
+		if bucket == nil {
+			continue
+		}
+
+		var keys [][]byte
+		err := bucket.ForEach(func(k, _ []byte) error {
+			keys = append(keys, append([]byte{}, k...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			value, err := toGob(record{
+				Version: 1,
+				Payload: bucket.Get(k),
+			})
+			if err != nil {
+				return err
+			}
+
+			err = bucket.Put(k, value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodes the stored settings, changes them with fn and stores them again,
+// databases without settings are left alone
+func migrateSettings(tx *bolt.Tx, fn func(sett *settings)) error {
+	bucketSettings := tx.Bucket([]byte("Settings"))
+	if bucketSettings == nil {
+		return nil
+	}
+
+	value := bucketSettings.Get([]byte("ALL"))
+	if value == nil {
+		return nil
+	}
+
+	sett, err := fromRecord[settings](value)
+	if err != nil {
+		return err
+	}
+	fn(&sett)
+
+	value, err = toRecord(sett)
+	if err != nil {
+		return err
+	}
+
+	return bucketSettings.Put([]byte("ALL"), value)
+}
+
+// settings written before the trash decode with 0 days, which keeps trashed
+// games forever, so they get the default instead
+func migrateTrashRetention(tx *bolt.Tx) error {
+	return migrateSettings(tx, func(sett *settings) {
+		sett.TrashRetentionDays = defaultSettings().TrashRetentionDays
+	})
+}
+
+// settings written before decode with no colour mode
+func migrateColorMode(tx *bolt.Tx) error {
+	return migrateSettings(tx, func(sett *settings) {
+		sett.ColorMode = defaultSettings().ColorMode
+	})
+}
+
+func migrateGlyphs(tx *bolt.Tx) error {
+	return migrateSettings(tx, func(sett *settings) {
+		sett.Glyphs = defaultSettings().Glyphs
+	})
+}
+
+// settings written before decode with 0 columns per cell
+func migrateFieldLayout(tx *bolt.Tx) error {
+	return migrateSettings(tx, func(sett *settings) {
+		sett.CellWidth = defaultSettings().CellWidth
+		sett.Grid = defaultSettings().Grid
+	})
+}
+
+func migrateStatusLine(tx *bolt.Tx) error {
+	return migrateSettings(tx, func(sett *settings) {
+		sett.StatusLine = defaultSettings().StatusLine
+	})
+}
+
+func migrateTimerDecimals(tx *bolt.Tx) error {
+	return migrateSettings(tx, func(sett *settings) {
+		sett.TimerDecimals = defaultSettings().TimerDecimals
+	})
+}
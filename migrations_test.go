@@ -0,0 +1,204 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// What the testdata/v0_*.gob fixtures hold, gob encoded by termines before
+// records were versioned.
+var (
+	fixtureId       = uuid.MustParse("6f1c2e4a-9b3d-4c5e-8f7a-1b2c3d4e5f60")
+	fixtureGameInfo = gameInfo{
+		Id:           fixtureId,
+		GameDuration: 3 * time.Second,
+		Result:       "WON",
+		MineCount:    1,
+		FieldWidth:   3,
+		FieldHeight:  2,
+		CreatedAt:    time.Date(2025, 6, 7, 8, 9, 10, 0, time.UTC),
+	}
+	fixtureGameData = gameData{
+		Id: fixtureId,
+		Field: [][]fieldCell{
+			{{Value: 0}, {Value: 1}, {Value: 9}},
+			{{Value: 0}, {Value: 1}, {Value: 1}},
+		},
+		History: []historyStep{
+			{CurrGameDuration: time.Second, Kind: "OPEN", OpenResult: "NONE"},
+			{CurrGameDuration: 2 * time.Second, Kind: "MOVE", MoveX: 2, MoveY: 1},
+			{CurrGameDuration: 3 * time.Second, Kind: "OPEN", OpenResult: "WON"},
+		},
+	}
+)
+
+// a database as termines wrote it before the schema version, with the fixtures in it
+func writeVersion0Database(t *testing.T, path string) {
+	t.Helper()
+
+	values := map[string][2]string{
+		"GameInfo": {fixtureId.String(), "v0_gameinfo.gob"},
+		"GameData": {fixtureId.String(), "v0_gamedata.gob"},
+		"Settings": {"ALL", "v0_settings.gob"},
+	}
+
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for name, kv := range values {
+			value, err := os.ReadFile(filepath.Join("testdata", kv[1]))
+			if err != nil {
+				return err
+			}
+
+			bucket, err := tx.CreateBucket([]byte(name))
+			if err != nil {
+				return err
+			}
+
+			err = bucket.Put([]byte(kv[0]), value)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateVersion0(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "data.db")
+	writeVersion0Database(t, path)
+
+	s, err := openBoltStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.close()
+
+	err = s.migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.db.View(func(tx *bolt.Tx) error {
+		if version := getSchemaVersion(tx); version != latestSchemaVersion() {
+			t.Errorf("schema version is %d after migrating, want %d", version, latestSchemaVersion())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gInfo, gData, err := s.loadGameInfoAndData(fixtureId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gInfo, fixtureGameInfo) {
+		t.Errorf("migrated info is %+v, want %+v", gInfo, fixtureGameInfo)
+	}
+	if !reflect.DeepEqual(gData, fixtureGameData) {
+		t.Errorf("migrated data is %+v, want %+v", gData, fixtureGameData)
+	}
+
+	// settings that didn't exist yet get their defaults
+	sett, err := s.getSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSettings := defaultSettings()
+	wantSettings.Theme = "DARK"
+	wantSettings.MaxScrolloff = 3
+	if !reflect.DeepEqual(sett, wantSettings) {
+		t.Errorf("migrated settings are %+v, want %+v", sett, wantSettings)
+	}
+
+	ids, err := s.queryGameIds(gameQuery{SortBy: "LATEST", FieldWidth: 3, FieldHeight: 2, FieldMineCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ids, []uuid.UUID{fixtureId}) {
+		t.Errorf("indexes find %v, want the migrated game", ids)
+	}
+
+	backupsDir, err := getBackupsDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backups, err := filepath.Glob(filepath.Join(backupsDir, "data-v0-*.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("backups of the version 0 database are %v, want one", backups)
+	}
+
+	// the backup is the database as it was, fixtures and all
+	backup, err := bolt.Open(backups[0], 0600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backup.Close()
+
+	want, err := os.ReadFile(filepath.Join("testdata", "v0_gameinfo.gob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = backup.View(func(tx *bolt.Tx) error {
+		if version := getSchemaVersion(tx); version != 0 {
+			t.Errorf("backup has schema version %d, want 0", version)
+		}
+		if got := tx.Bucket([]byte("GameInfo")).Get([]byte(fixtureId.String())); string(got) != string(want) {
+			t.Error("backup's game info isn't the version 0 encoding")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// a version 0 value is plain gob, not a record, and must not decode as one
+func TestFromRecordVersion0(t *testing.T) {
+	value, err := os.ReadFile(filepath.Join("testdata", "v0_settings.gob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = fromRecord[settings](value)
+	if err == nil {
+		t.Error("version 0 settings decoded as a record")
+	}
+
+	sett, err := fromGob[settings](value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sett.Theme != "DARK" || sett.MaxScrolloff != 3 {
+		t.Errorf("version 0 settings decode to %+v", sett)
+	}
+
+	rec, err := toRecord(sett)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := fromRecord[settings](rec)
+	if err != nil || !reflect.DeepEqual(decoded, sett) {
+		t.Errorf("record round trip gives %+v, %v", decoded, err)
+	}
+}
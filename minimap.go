@@ -0,0 +1,120 @@
+package main
+
+// most columns the minimap takes, it never takes more than a quarter of the screen
+const MINIMAP_MAX_WIDTH int = 32
+
+// braille dots of a character, by column and row of the dot
+var minimapDots = [2][4]rune{
+	{0x01, 0x02, 0x04, 0x40},
+	{0x08, 0x10, 0x20, 0x80},
+}
+
+// field of the game being played or replayed, nil in the menu
+func (a *app) gameField() [][]fieldCell {
+	switch a.state {
+	case "PLAY":
+		return a.play.field
+	case "REPLAY":
+		return a.replay.gData.Field
+	}
+
+	return nil
+}
+
+// Field cells every braille dot stands for, so the whole field fits in
+// columns by rows characters of 2 by 4 dots.
+func minimapScale(fieldWidth, fieldHeight, columns, rows int) (scaleX, scaleY int) {
+	scaleX = max((fieldWidth+columns*2-1)/(columns*2), 1)
+	scaleY = max((fieldHeight+rows*4-1)/(rows*4), 1)
+	return
+}
+
+// Columns and rows of the minimap in a screen of width by height, 0 when
+// it is off or the whole field fits without it.
+func (a *app) minimapSize(width, height int) (int, int) {
+	field := a.gameField()
+	if !a.minimapActive || len(field) == 0 || height <= 0 {
+		return 0, 0
+	}
+
+	l := a.fieldLayout()
+	if l.cellsFitting(width, l.cellWidth) >= len(field[0]) && l.cellsFitting(height, 1) >= len(field) {
+		return 0, 0
+	}
+
+	// and a column for the line between the field and the minimap
+	maxColumns := min(MINIMAP_MAX_WIDTH, width/4-1)
+	if maxColumns <= 0 {
+		return 0, 0
+	}
+
+	scaleX, scaleY := minimapScale(len(field[0]), len(field), maxColumns, height)
+	columns := (len(field[0]) + scaleX*2 - 1) / (scaleX * 2)
+	rows := (len(field) + scaleY*4 - 1) / (scaleY * 4)
+
+	return columns, rows
+}
+
+// Open cells are dots, characters with flags or opened mines take their
+// styles and the part of the field on the screen is reversed.
+func (a *app) drawMinimap(field [][]fieldCell, scrollX, scrollY int) {
+	xOffset, yOffset, fieldScreenWidth, fieldScreenHeight := a.getFieldScreenSize()
+	terminalWidth, terminalHeight := a.screen.Size()
+	columns, rows := a.minimapSize(terminalWidth-xOffset, terminalHeight-yOffset)
+	if columns == 0 {
+		return
+	}
+
+	minimapX := terminalWidth - columns
+	for y := yOffset; y < terminalHeight; y++ {
+		a.screen.SetContent(minimapX-1, y, '│', nil, a.defStyle)
+	}
+
+	scaleX, scaleY := minimapScale(len(field[0]), len(field), columns, rows)
+	for row := range rows {
+		for column := range columns {
+			fromX, toX := column*2*scaleX, min((column+1)*2*scaleX, len(field[0]))
+			fromY, toY := row*4*scaleY, min((row+1)*4*scaleY, len(field))
+
+			rune := rune(0x2800)
+			var flag, mine bool
+			for y := fromY; y < toY; y++ {
+				for x := fromX; x < toX; x++ {
+					cell := field[y][x]
+					if cell.State == CELL_STATE_FLAG {
+						flag = true
+					}
+					if cell.State == CELL_STATE_OPEN {
+						rune |= minimapDots[(x-fromX)/scaleX][(y-fromY)/scaleY]
+						mine = mine || cell.Value == CELL_VALUE_MINE
+					}
+				}
+			}
+
+			style := a.defStyle
+			if mine {
+				style = a.theme.mine.Bold(true)
+			} else if flag {
+				style = a.theme.flag.Bold(true)
+			}
+
+			if fromX < scrollX+fieldScreenWidth && toX > scrollX && fromY < scrollY+fieldScreenHeight && toY > scrollY {
+				style = style.Reverse(true)
+			}
+
+			a.screen.SetContent(minimapX+column, yOffset+row, rune, nil, style)
+		}
+	}
+}
+
+func (a *app) toggleMinimap() {
+	a.minimapActive = !a.minimapActive
+
+	// the field gets narrower or wider
+	switch a.state {
+	case "PLAY":
+		a.play.fieldCurrScrollX, a.play.fieldCurrScrollY = a.alignField(a.play.fieldCurrX, a.play.fieldCurrY, a.play.fieldCurrScrollX, a.play.fieldCurrScrollY)
+	case "REPLAY":
+		a.replay.rInfo.currScrollX, a.replay.rInfo.currScrollY = a.alignField(a.replay.rInfo.currX, a.replay.rInfo.currY, a.replay.rInfo.currScrollX, a.replay.rInfo.currScrollY)
+	}
+}
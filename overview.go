@@ -0,0 +1,199 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// The whole field scaled down to the screen, a character is two blocks of
+// field cells on top of each other drawn as a half block
+type overview struct {
+	active bool
+	// character picked to jump to
+	x int
+	y int
+}
+
+// colours of the blocks, from the 8 every terminal has so they are told
+// apart in every theme and colour mode
+var overviewColors = map[string]tcell.Color{
+	"HIDDEN": tcell.ColorNavy,
+	"OPEN":   tcell.ColorSilver,
+	"FLAG":   tcell.ColorOlive,
+	"MINE":   tcell.ColorMaroon,
+}
+
+// Field cells a block stands for and the characters the scaled down field
+// takes, it fills the screen below the header.
+func (a *app) overviewScale(field [][]fieldCell) (scaleX, scaleY, columns, rows int) {
+	xOffset, yOffset, _, _ := a.getFieldScreenSize()
+	terminalWidth, terminalHeight := a.screen.Size()
+	width := max(terminalWidth-xOffset, 1)
+	height := max(terminalHeight-yOffset, 1)
+
+	scaleX = max((len(field[0])+width-1)/width, 1)
+	scaleY = max((len(field)+height*2-1)/(height*2), 1)
+	columns = (len(field[0]) + scaleX - 1) / scaleX
+	rows = (len(field) + scaleY*2 - 1) / (scaleY * 2)
+
+	return
+}
+
+// MINE or FLAG when the block has one, OPEN when at least half of it is
+// open, HIDDEN otherwise and NONE past the field's edge
+func overviewBlockKind(field [][]fieldCell, fromX, toX, fromY, toY int) string {
+	toX = min(toX, len(field[0]))
+	toY = min(toY, len(field))
+	if fromX >= toX || fromY >= toY {
+		return "NONE"
+	}
+
+	var flag, mine bool
+	openCount := 0
+	for y := fromY; y < toY; y++ {
+		for x := fromX; x < toX; x++ {
+			switch field[y][x].State {
+			case CELL_STATE_FLAG:
+				flag = true
+			case CELL_STATE_OPEN:
+				openCount++
+				mine = mine || field[y][x].Value == CELL_VALUE_MINE
+			}
+		}
+	}
+	switch {
+	case mine:
+		return "MINE"
+	case flag:
+		return "FLAG"
+	case openCount*2 >= (toX-fromX)*(toY-fromY):
+		return "OPEN"
+	}
+
+	return "HIDDEN"
+}
+
+func (a *app) overviewColor(kind string) tcell.Color {
+	c, _ := fitColor(overviewColors[kind], a.colors())
+	return c
+}
+
+func (a *app) drawOverview(field [][]fieldCell) {
+	xOffset, yOffset, _, _ := a.getFieldScreenSize()
+	scaleX, scaleY, columns, rows := a.overviewScale(field)
+	a.overview.x = min(a.overview.x, columns-1)
+	a.overview.y = min(a.overview.y, rows-1)
+
+	for row := range rows {
+		for column := range columns {
+			fromX, toX := column*scaleX, (column+1)*scaleX
+			topKind := overviewBlockKind(field, fromX, toX, row*2*scaleY, (row*2+1)*scaleY)
+			bottomKind := overviewBlockKind(field, fromX, toX, (row*2+1)*scaleY, (row*2+2)*scaleY)
+
+			style := a.defStyle.Foreground(a.overviewColor(topKind))
+			if bottomKind != "NONE" {
+				style = style.Background(a.overviewColor(bottomKind))
+			}
+
+			rune := '▀'
+			if column == a.overview.x && row == a.overview.y {
+				rune = '◆'
+				style = style.Foreground(a.overviewColor("MINE")).Bold(true)
+				if topKind == "MINE" || bottomKind == "MINE" {
+					style = style.Foreground(a.overviewColor("OPEN"))
+				}
+			}
+
+			a.screen.SetContent(xOffset+column, yOffset+row, rune, nil, style)
+		}
+	}
+}
+
+// starts on the cursor
+func (a *app) openOverview(field [][]fieldCell, cursorX, cursorY int) {
+	scaleX, scaleY, _, _ := a.overviewScale(field)
+	a.overview = overview{
+		active: true,
+		x:      cursorX / scaleX,
+		y:      cursorY / (scaleY * 2),
+	}
+}
+
+// Moves the cursor of a game being played to the middle of the picked
+// character and scrolls the field to have it in the middle of the screen.
+// A replay's cursor follows its steps, so only its field scrolls there.
+func (a *app) jumpOverview() {
+	field := a.gameField()
+	scaleX, scaleY, _, _ := a.overviewScale(field)
+	a.overview.active = false
+
+	fieldX := min(a.overview.x*scaleX+scaleX/2, len(field[0])-1)
+	fieldY := min(a.overview.y*scaleY*2+scaleY, len(field)-1)
+
+	_, _, fieldScreenWidth, fieldScreenHeight := a.getFieldScreenSize()
+	scrollX := max(min(fieldX-fieldScreenWidth/2, len(field[0])-fieldScreenWidth), 0)
+	scrollY := max(min(fieldY-fieldScreenHeight/2, len(field)-fieldScreenHeight), 0)
+
+	switch a.state {
+	case "PLAY":
+		moved := fieldX != a.play.fieldCurrX || fieldY != a.play.fieldCurrY
+		a.play.fieldCurrX, a.play.fieldCurrY = fieldX, fieldY
+		a.play.fieldCurrScrollX, a.play.fieldCurrScrollY = a.alignField(fieldX, fieldY, scrollX, scrollY)
+
+		if a.play.started && moved {
+			a.play.history = append(a.play.history, historyStep{
+				CurrGameDuration: time.Since(a.play.startTime),
+				Kind:             "MOVE",
+				MoveX:            a.play.fieldCurrX,
+				MoveY:            a.play.fieldCurrY,
+			})
+		}
+	case "REPLAY":
+		a.replay.rInfo.currScrollX, a.replay.rInfo.currScrollY = scrollX, scrollY
+	}
+}
+
+func (a *app) eventKeyOverview(key tcell.Key, rune rune) {
+	if key == tcell.KeyEnter || rune == ' ' || rune == 'd' {
+		a.jumpOverview()
+		return
+	}
+
+	if key == tcell.KeyEscape || rune == 'q' || rune == 'z' {
+		a.overview.active = false
+		return
+	}
+
+	_, _, columns, rows := a.overviewScale(a.gameField())
+
+	if rune == 'k' || key == tcell.KeyUp {
+		a.overview.y = max(a.overview.y-1, 0)
+	}
+	if rune == 'j' || key == tcell.KeyDown {
+		a.overview.y = min(a.overview.y+1, rows-1)
+	}
+	if rune == 'h' || key == tcell.KeyLeft {
+		a.overview.x = max(a.overview.x-1, 0)
+	}
+	if rune == 'l' || key == tcell.KeyRight {
+		a.overview.x = min(a.overview.x+1, columns-1)
+	}
+}
+
+// left click jumps to the clicked character
+func (a *app) eventMouseOverview(ev *tcell.EventMouse) {
+	if ev.Buttons()&tcell.Button1 == 0 {
+		return
+	}
+
+	xOffset, yOffset, _, _ := a.getFieldScreenSize()
+	_, _, columns, rows := a.overviewScale(a.gameField())
+	x, y := ev.Position()
+	if x < xOffset || y < yOffset || x >= xOffset+columns || y >= yOffset+rows {
+		return
+	}
+
+	a.overview.x, a.overview.y = x-xOffset, y-yOffset
+	a.jumpOverview()
+}
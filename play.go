@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"math/rand/v2"
 	"strconv"
 	"time"
 
@@ -21,11 +22,21 @@ type play struct {
 	started          bool
 	timeChan         chan struct{}
 	lastSPress       time.Time
+	// the mines were placed with
+	seed uint64
+	// reset when cells open
+	field3BV cached3BV
+
+	// typing a coordinate after :
+	jumpActive  bool
+	jumpInput   string
+	jumpMessage string
 }
 
 func createPlay(width, height, mineCount int) play {
+	seed := rand.Uint64()
 	var field [][]fieldCell
-	field = createField(width, height, mineCount)
+	field = createField(width, height, mineCount, seed)
 
 	return play{
 		fieldCurrX:       0,
@@ -37,10 +48,13 @@ func createPlay(width, height, mineCount int) play {
 		field:            field,
 		started:          false,
 		lastSPress:       time.Now().Add(-time.Minute),
+		seed:             seed,
 	}
 }
 
 func (a *app) drawPlay() {
+	a.drawHeaderBackground()
+
 	if a.play.startingStats {
 		width := len(a.play.field[0])
 		height := len(a.play.field)
@@ -50,13 +64,8 @@ func (a *app) drawPlay() {
 		mineCountStr := strconv.Itoa(mineCount)
 		mineDensityStr := fmt.Sprintf("%.2f%%", getMineDensity(width, height, mineCount))
 		startingStatsStr := fieldWidthStr + "x" + fieldHeightStr + "(" + mineCountStr + ")" + " " + mineDensityStr
-		a.setContentString(0, 0, a.defStyle, startingStatsStr)
+		a.setContentString(0, 0, a.theme.header, startingStatsStr)
 	} else {
-		currStart := 0
-		minesLeftStr := "Mines Left:" + strconv.Itoa(minesLeft(a.play.field))
-		a.setContentString(currStart, 0, a.defStyle, minesLeftStr)
-		currStart += len(minesLeftStr) + 3
-
 		var timePassed time.Duration
 		if a.play.started {
 			timePassed = time.Since(a.play.startTime)
@@ -64,25 +73,57 @@ func (a *app) drawPlay() {
 			timePassed = 0
 		}
 
-		secondsStr := "Time:" + strconv.Itoa(int(timePassed.Seconds()))
-		a.setContentString(currStart, 0, a.defStyle, secondsStr)
-		currStart += len(secondsStr) + 3
+		solved3BV, total3BV := a.statusLine3BV(&a.play.field3BV, a.play.field)
+		a.drawStatusLine(statusInfo{
+			time:          timePassed,
+			timerDecimals: a.settings.TimerDecimals,
+			width:         len(a.play.field[0]),
+			height:        len(a.play.field),
+			mineCount:     totalMineCount(a.play.field),
+			minesLeft:     minesLeft(a.play.field),
+			cursorX:       a.play.fieldCurrX,
+			cursorY:       a.play.fieldCurrY,
+			solved3BV:     solved3BV,
+			total3BV:      total3BV,
+			seed:          a.play.seed,
+		})
+	}
+
+	if a.play.jumpActive {
+		a.drawPlayJump()
+	}
+
+	if a.overview.active {
+		a.drawOverview(a.play.field)
+		return
 	}
 
 	a.drawField(a.play.fieldCurrX, a.play.fieldCurrY, a.play.fieldCurrScrollX, a.play.fieldCurrScrollY, a.play.field)
+	a.drawMinimap(a.play.field, a.play.fieldCurrScrollX, a.play.fieldCurrScrollY)
 }
 
 func (a *app) eventKeyPlay(ev *tcell.EventKey) {
 	rune := ev.Rune()
 	key := ev.Key()
 
+	if a.overview.active {
+		a.eventKeyOverview(key, rune)
+		return
+	}
+
+	// coordinates can contain keys that move
+	if a.play.jumpActive {
+		a.eventKeyPlayJump(key, rune)
+		return
+	}
+
 	if rune == 'q' {
 		if time.Since(a.play.lastQPress).Abs() < time.Second/2 {
 			if a.play.started {
 				a.play.started = false
 				close(a.play.timeChan)
 			}
-			a.state = "MENU"
+			a.setState("MENU")
 		} else {
 			a.play.lastQPress = time.Now()
 		}
@@ -107,6 +148,20 @@ func (a *app) eventKeyPlay(ev *tcell.EventKey) {
 		a.play.startingStats = !a.play.startingStats
 	}
 
+	if rune == 'M' {
+		a.toggleMinimap()
+	}
+
+	if rune == 'z' {
+		a.openOverview(a.play.field, a.play.fieldCurrX, a.play.fieldCurrY)
+	}
+
+	if rune == ':' {
+		a.play.jumpActive = true
+		a.play.jumpInput = ""
+		a.play.jumpMessage = ""
+	}
+
 	if rune == 'k' || (key == tcell.KeyUp && !bigArrowMove) {
 		a.play.fieldCurrX, a.play.fieldCurrY = moveField(a.play.field, a.play.fieldCurrX, a.play.fieldCurrY, false, "UP", 1)
 	}
@@ -147,6 +202,7 @@ func (a *app) eventKeyPlay(ev *tcell.EventKey) {
 		}
 
 		result := openField(a.play.field, a.play.fieldCurrX, a.play.fieldCurrY)
+		a.play.field3BV = cached3BV{}
 
 		a.play.history = append(a.play.history, historyStep{
 			CurrGameDuration: time.Since(a.play.startTime),
@@ -157,17 +213,17 @@ func (a *app) eventKeyPlay(ev *tcell.EventKey) {
 		if result != "NONE" {
 			a.play.started = false
 			close(a.play.timeChan)
-			gInfo := createGameInfo(result, a.play.history, a.play.field)
+			gInfo := createGameInfo(result, a.play.history, a.play.field, a.play.seed)
 
 			a.replay.gInfo = gInfo
 			a.replay.gData = gameData{
 				Field:   closeFieldCopy(a.play.field),
 				History: a.play.history,
 			}
+			// the replay's field is laid out below its timeline
+			a.setState("REPLAY")
 			a.replay.rInfo = a.createReplayInfo(a.replay.gData)
 
-			a.state = "REPLAY"
-
 			historyCopy := make([]historyStep, len(a.play.history))
 			copy(historyCopy, a.play.history)
 			fieldCopy := closeFieldCopy(a.play.field)
@@ -241,9 +297,50 @@ func (a *app) eventKeyPlay(ev *tcell.EventKey) {
 	}
 }
 
+// left click moves the cursor to the clicked cell
+func (a *app) eventMousePlay(ev *tcell.EventMouse) {
+	if a.overview.active {
+		a.eventMouseOverview(ev)
+		return
+	}
+
+	if ev.Buttons()&tcell.Button1 == 0 {
+		return
+	}
+
+	xOffset, yOffset, fieldScreenWidth, fieldScreenHeight := a.getFieldScreenSize()
+	l := a.fieldLayout()
+	x, y := ev.Position()
+
+	screenX, okX := l.cellAt(a.play.fieldCurrScrollX, x-xOffset, l.cellWidth, min(fieldScreenWidth, len(a.play.field[0])-a.play.fieldCurrScrollX))
+	screenY, okY := l.cellAt(a.play.fieldCurrScrollY, y-yOffset, 1, min(fieldScreenHeight, len(a.play.field)-a.play.fieldCurrScrollY))
+	if !okX || !okY {
+		return
+	}
+
+	fieldX := screenX + a.play.fieldCurrScrollX
+	fieldY := screenY + a.play.fieldCurrScrollY
+	if fieldX == a.play.fieldCurrX && fieldY == a.play.fieldCurrY {
+		return
+	}
+
+	a.play.fieldCurrX, a.play.fieldCurrY = fieldX, fieldY
+	a.play.fieldCurrScrollX, a.play.fieldCurrScrollY = a.alignField(a.play.fieldCurrX, a.play.fieldCurrY, a.play.fieldCurrScrollX, a.play.fieldCurrScrollY)
+
+	if a.play.started {
+		a.play.history = append(a.play.history, historyStep{
+			CurrGameDuration: time.Since(a.play.startTime),
+			Kind:             "MOVE",
+			MoveX:            a.play.fieldCurrX,
+			MoveY:            a.play.fieldCurrY,
+		})
+	}
+}
+
 func (a *app) startGame() {
 	a.play.startTime = time.Now()
-	ticker := time.NewTicker(time.Second)
+	// as often as the timer's last decimal place changes
+	ticker := time.NewTicker(timerInterval(a.settings.TimerDecimals))
 	quit := make(chan struct{})
 	a.play.started = true
 	a.play.timeChan = quit
@@ -256,9 +353,8 @@ func (a *app) startGame() {
 			case <-a.ctx.Done():
 				return
 			case <-ticker.C:
-				a.screen.Clear()
-				a.draw()
-				a.screen.Show()
+				// the event loop redraws, a dropped one only skips a tick of the timer
+				a.screen.PostEvent(tcell.NewEventInterrupt(nil))
 			case <-quit:
 				ticker.Stop()
 				return
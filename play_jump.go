@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// Coordinates as the status line shows them, x,y counted from 1 at the
+// top left. A space works instead of the comma.
+func parseCoordinate(str string, width, height int) (x, y int, err error) {
+	xStr, yStr, ok := strings.Cut(strings.ReplaceAll(strings.TrimSpace(str), " ", ","), ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("type x,y")
+	}
+
+	x, errX := strconv.Atoi(strings.Trim(xStr, ","))
+	y, errY := strconv.Atoi(strings.Trim(yStr, ","))
+	if errX != nil || errY != nil {
+		return 0, 0, fmt.Errorf("type x,y")
+	}
+
+	if x < 1 || x > width || y < 1 || y > height {
+		return 0, 0, fmt.Errorf("outside of %dx%d", width, height)
+	}
+
+	return x - 1, y - 1, nil
+}
+
+func (a *app) eventKeyPlayJump(key tcell.Key, rune rune) {
+	switch key {
+	case tcell.KeyEscape:
+		a.play.jumpActive = false
+	case tcell.KeyEnter:
+		x, y, err := parseCoordinate(a.play.jumpInput, len(a.play.field[0]), len(a.play.field))
+		if err != nil {
+			a.play.jumpMessage = err.Error()
+			return
+		}
+		a.play.jumpActive = false
+
+		moved := x != a.play.fieldCurrX || y != a.play.fieldCurrY
+		a.play.fieldCurrX, a.play.fieldCurrY = x, y
+		a.play.fieldCurrScrollX, a.play.fieldCurrScrollY = a.alignField(a.play.fieldCurrX, a.play.fieldCurrY, a.play.fieldCurrScrollX, a.play.fieldCurrScrollY)
+
+		if a.play.started && moved {
+			a.play.history = append(a.play.history, historyStep{
+				CurrGameDuration: time.Since(a.play.startTime),
+				Kind:             "MOVE",
+				MoveX:            a.play.fieldCurrX,
+				MoveY:            a.play.fieldCurrY,
+			})
+		}
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		_, size := utf8.DecodeLastRuneInString(a.play.jumpInput)
+		a.play.jumpInput = a.play.jumpInput[:len(a.play.jumpInput)-size]
+		a.play.jumpMessage = ""
+	case tcell.KeyRune:
+		a.play.jumpInput += string(rune)
+		a.play.jumpMessage = ""
+	}
+}
+
+// drawn over the header while a coordinate is typed
+func (a *app) drawPlayJump() {
+	width, _ := a.screen.Size()
+	for x := range width {
+		a.screen.SetContent(x, 0, ' ', nil, a.defStyle)
+	}
+
+	promptStr := ":" + a.play.jumpInput
+	a.setContentString(0, 0, a.defStyle, promptStr)
+	a.screen.SetContent(runewidth.StringWidth(promptStr), 0, ' ', nil, a.defStyle.Reverse(true))
+
+	if a.play.jumpMessage != "" {
+		a.setContentString(runewidth.StringWidth(promptStr)+2, 0, a.defStyle, a.play.jumpMessage)
+	}
+}
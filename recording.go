@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// how long the final frame stays on screen
+const RECORDING_END_HOLD time.Duration = 3 * time.Second
+
+type asciicastHeader struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title"`
+}
+
+type recordingResult struct {
+	path string
+	err  error
+}
+
+// copies everything the recording needs, so it can run in the background
+// while the replay keeps being used, the result goes back to the event loop
+func (a *app) startReplayRecording(kind string) {
+	gInfo := a.replay.gInfo
+	history := make([]historyStep, len(a.replay.gData.History))
+	copy(history, a.replay.gData.History)
+	gData := gameData{
+		Id:      a.replay.gData.Id,
+		Field:   closeFieldCopy(a.replay.gData.Field),
+		History: history,
+	}
+	width, height := a.screen.Size()
+
+	// the settings, theme and glyphs the recording is drawn with
+	sett := a.settings
+	sett.StatusLine = slices.Clone(sett.StatusLine)
+	r := &app{
+		ctx:      a.ctx,
+		cancel:   func() {},
+		state:    "REPLAY",
+		settings: sett,
+		defStyle: a.defStyle,
+		theme:    a.theme,
+	}
+
+	a.replay.rInfo.message = "Exporting..."
+
+	a.wg.Add(1)
+	safeGo(func() {
+		defer a.wg.Done()
+
+		var res recordingResult
+		switch kind {
+		case "CAST":
+			res.path, res.err = r.exportReplayCast(gInfo, gData, width, height)
+		case "GIF":
+			res.path, res.err = r.exportReplayGIF(gInfo, gData)
+		}
+
+		select {
+		case a.recordingDone <- res:
+		case <-a.ctx.Done():
+		}
+	}, a.screen)
+}
+
+func (a *app) finishReplayRecording(res recordingResult) {
+	if res.err != nil {
+		a.log(res.err)
+		a.replay.rInfo.message = "Export failed"
+		return
+	}
+
+	a.replay.rInfo.message = "Exported:" + res.path
+}
+
+func getRecordingPath(gInfo gameInfo, extension string) (string, error) {
+	exportsDir, err := getExportsDir()
+	if err != nil {
+		return "", err
+	}
+
+	name := gInfo.CreatedAt.Format("2006-01-02_15-04-05") + "_replay." + extension
+	return filepath.Join(exportsDir, name), nil
+}
+
+// renders every step with drawReplay on a simulated screen of the given size
+func (a *app) exportReplayCast(gInfo gameInfo, gData gameData, width, height int) (string, error) {
+	path, err := getRecordingPath(gInfo, "cast")
+	if err != nil {
+		return "", err
+	}
+
+	sim := tcell.NewSimulationScreen("UTF-8")
+	err = sim.Init()
+	if err != nil {
+		return "", err
+	}
+	defer sim.Fini()
+	sim.SetSize(width, height)
+	sim.SetStyle(a.defStyle)
+
+	r := &app{
+		ctx:      a.ctx,
+		cancel:   func() {},
+		state:    "REPLAY",
+		screen:   sim,
+		settings: a.settings,
+		defStyle: a.defStyle,
+		theme:    a.theme,
+		replay: gameReplay{
+			gInfo: gInfo,
+			gData: gData,
+			rInfo: replayInfo{
+				speedIdx: slices.Index(replaySpeeds, 1),
+			},
+		},
+	}
+	closeField(r.replay.gData.Field)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+
+	header, err := json.Marshal(asciicastHeader{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: gInfo.CreatedAt.Unix(),
+		Title:     "termines " + gInfo.Result + " " + strconv.Itoa(gInfo.FieldWidth) + "x" + strconv.Itoa(gInfo.FieldHeight) + "(" + strconv.Itoa(gInfo.MineCount) + ")",
+	})
+	if err != nil {
+		return "", err
+	}
+	w.Write(header)
+	w.WriteString("\n")
+
+	var x, y, scrollX, scrollY int
+	for step := -1; step < len(gData.History); step++ {
+		if step >= 0 {
+			x, y, err = nextStep(r.replay.gData.Field, x, y, gData.History[step])
+			if err != nil {
+				return "", err
+			}
+			scrollX, scrollY = r.alignField(x, y, scrollX, scrollY)
+		}
+		r.replay.rInfo.currStepIdx = step
+		r.replay.rInfo.field3BV = cached3BV{}
+		r.replay.rInfo.currX, r.replay.rInfo.currY = x, y
+		r.replay.rInfo.currScrollX, r.replay.rInfo.currScrollY = scrollX, scrollY
+
+		sim.Clear()
+		r.drawReplay()
+		sim.Show()
+
+		var at time.Duration
+		if step >= 0 {
+			at = gData.History[step].CurrGameDuration
+		}
+
+		event, err := json.Marshal([]any{at.Seconds(), "o", simScreenToANSI(sim)})
+		if err != nil {
+			return "", err
+		}
+		w.Write(event)
+		w.WriteString("\n")
+	}
+
+	return path, w.Flush()
+}
+
+func simScreenToANSI(sim tcell.SimulationScreen) string {
+	cells, width, height := sim.GetContents()
+
+	var sb strings.Builder
+	sb.WriteString("\x1b[H")
+
+	for y := range height {
+		if y > 0 {
+			sb.WriteString("\r\n")
+		}
+
+		var lastStyle tcell.Style
+		first := true
+		for x := range width {
+			cell := cells[y*width+x]
+			// the column a double width rune spills into
+			if x > 0 && len(cell.Runes) == 0 && runewidth.StringWidth(string(cells[y*width+x-1].Runes)) == 2 {
+				continue
+			}
+			if first || cell.Style != lastStyle {
+				sb.WriteString(styleToSGR(cell.Style))
+				lastStyle = cell.Style
+				first = false
+			}
+
+			if len(cell.Runes) == 0 {
+				sb.WriteRune(' ')
+			} else {
+				sb.WriteString(string(cell.Runes))
+			}
+		}
+		sb.WriteString("\x1b[0m")
+	}
+
+	return sb.String()
+}
+
+func styleToSGR(style tcell.Style) string {
+	fg, bg, attrs := style.Decompose()
+
+	params := []string{"0"}
+	if attrs&tcell.AttrBold != 0 {
+		params = append(params, "1")
+	}
+	if attrs&tcell.AttrUnderline != 0 {
+		params = append(params, "4")
+	}
+	if attrs&tcell.AttrReverse != 0 {
+		params = append(params, "7")
+	}
+	params = append(params, colorToSGR(fg, "38", "39"), colorToSGR(bg, "48", "49"))
+
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+func colorToSGR(c tcell.Color, prefix string, reset string) string {
+	if !c.Valid() {
+		return reset
+	}
+
+	if c.IsRGB() {
+		r, g, b := c.RGB()
+		return prefix + ";2;" + strconv.Itoa(int(r)) + ";" + strconv.Itoa(int(g)) + ";" + strconv.Itoa(int(b))
+	}
+
+	return prefix + ";5;" + strconv.Itoa(int(c-tcell.ColorValid))
+}
+
+// Every frame only stores the rectangle of the cells that changed since the
+// previous one. They are redrawn into one image from the diffs replaySteps
+// keeps of each step, with the cells the cursor left and moved to.
+func (a *app) exportReplayGIF(gInfo gameInfo, gData gameData) (string, error) {
+	path, err := getRecordingPath(gInfo, "gif")
+	if err != nil {
+		return "", err
+	}
+
+	steps := createReplaySteps(gData.Field, gData.History)
+	field := gData.Field
+
+	pal := a.recordingPalette()
+	anim := gif.GIF{}
+
+	img := a.renderFieldImage(field, 0, 0)
+	var x, y int
+	for step := -1; step < len(gData.History); step++ {
+		bounds := img.Bounds()
+		if step >= 0 {
+			err = steps.seek(step)
+			if err != nil {
+				return "", err
+			}
+
+			prevX, prevY := x, y
+			x, y = steps.position()
+
+			bounds = a.drawFieldImageCell(img, field, prevX, prevY, x, y)
+			bounds = bounds.Union(a.drawFieldImageCell(img, field, x, y, x, y))
+			for _, c := range steps.diffs[step] {
+				bounds = bounds.Union(a.drawFieldImageCell(img, field, c.X, c.Y, x, y))
+			}
+		}
+
+		frame := image.NewPaletted(bounds, pal)
+		draw.Draw(frame, bounds, img, bounds.Min, draw.Src)
+
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, recordingDelay(gData.History, step))
+		anim.Disposal = append(anim.Disposal, gif.DisposalNone)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return path, gif.EncodeAll(file, &anim)
+}
+
+// in 100ths of a second, time until the step after this one
+func recordingDelay(history []historyStep, step int) int {
+	var delay time.Duration
+	if step+1 < len(history) {
+		var curr time.Duration
+		if step >= 0 {
+			curr = history[step].CurrGameDuration
+		}
+		delay = (history[step+1].CurrGameDuration - curr).Abs()
+	} else {
+		delay = RECORDING_END_HOLD
+	}
+
+	// most viewers ignore delays below 2
+	return max(int(delay/(10*time.Millisecond)), 2)
+}
+
+// every colour a rendered field can contain with the current theme
+func (a *app) recordingPalette() color.Palette {
+	pal := color.Palette{}
+	seen := map[color.RGBA]bool{}
+
+	add := func(c color.RGBA) {
+		if !seen[c] {
+			seen[c] = true
+			pal = append(pal, c)
+		}
+	}
+
+	for value := 0; value <= CELL_VALUE_MINE; value++ {
+		for _, state := range []int{CELL_STATE_HIDDEN, CELL_STATE_FLAG, CELL_STATE_OPEN} {
+			_, style := a.cellToStyle(fieldCell{Value: value, State: state})
+			for _, s := range []tcell.Style{style, a.theme.cursorStyle(style)} {
+				fg, bg := a.styleToRGBA(s)
+				add(fg)
+				add(bg)
+			}
+		}
+	}
+
+	return pal
+}
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"fmt"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/google/uuid"
 )
 
+const REPLAY_SEEK_TIME time.Duration = 5 * time.Second
+const REPLAY_PROGRESS_WIDTH int = 20
+
+var replaySpeeds = []float64{0.25, 0.5, 1, 2, 4, 8}
+
 type gameReplay struct {
 	gInfo gameInfo
 	gData gameData
@@ -22,6 +31,12 @@ type gameInfo struct {
 	FieldWidth   int
 	FieldHeight  int
 	CreatedAt    time.Time
+	// of createField, 0 for games saved before seeds were kept
+	Seed uint64
+
+	Starred bool
+	Tags    []string
+	Note    string
 }
 
 type gameData struct {
@@ -32,6 +47,7 @@ type gameData struct {
 
 type replayInfo struct {
 	currStepIdx int
+	steps       *replaySteps
 
 	currX       int
 	currY       int
@@ -41,16 +57,41 @@ type replayInfo struct {
 	lastMPress     time.Time
 	autoplayActive bool
 	stopAutoplay   chan struct{}
+	// index into replaySpeeds
+	speedIdx int
+
+	// shown at the end of the header, e.g. where an export was written
+	message string
+
+	// reset when the field moves to another step
+	field3BV cached3BV
+
+	// NONE, TAGS, NOTE
+	inputState string
+	input      string
 }
 
-// modifies gData.Field
+// the zero value has no input either, so replays built elsewhere don't need NONE
+func (r replayInfo) inputActive() bool {
+	return r.inputState != "" && r.inputState != "NONE"
+}
+
+// modifies gData.Field, a.state must already be REPLAY as the field is
+// scrolled for the replay's screen
 func (a *app) createReplayInfo(gData gameData) replayInfo {
-	closeField(gData.Field)
+	steps := createReplaySteps(gData.Field, gData.History)
+	err := steps.seek(len(gData.History) - 1)
+	if err != nil {
+		a.log(err)
+		a.cancel()
+	}
 
-	currX, currY, currScrollX, currScrollY := a.stepsFromZeroTo(gData.Field, gData.History, len(gData.History)-1)
+	currX, currY := steps.position()
+	currScrollX, currScrollY := a.alignField(currX, currY, 0, 0)
 
 	return replayInfo{
 		currStepIdx: len(gData.History) - 1,
+		steps:       steps,
 
 		currX:       currX,
 		currY:       currY,
@@ -60,10 +101,14 @@ func (a *app) createReplayInfo(gData gameData) replayInfo {
 		lastMPress:     time.Now().Add(-time.Minute),
 		stopAutoplay:   nil,
 		autoplayActive: false,
+		speedIdx:       slices.Index(replaySpeeds, 1),
+
+		inputState: "NONE",
+		input:      "",
 	}
 }
 
-func createGameInfo(result string, history []historyStep, field [][]fieldCell) gameInfo {
+func createGameInfo(result string, history []historyStep, field [][]fieldCell, seed uint64) gameInfo {
 	id := uuid.New()
 
 	return gameInfo{
@@ -74,47 +119,86 @@ func createGameInfo(result string, history []historyStep, field [][]fieldCell) g
 		FieldWidth:   len(field[0]),
 		FieldHeight:  len(field),
 		CreatedAt:    time.Now(),
+		Seed:         seed,
 	}
 }
 
 func (a *app) drawReplay() {
-	currStart := 0
-	resultStr := a.replay.gInfo.Result
-	a.setContentString(currStart, 0, a.defStyle, resultStr)
-	currStart += len(resultStr) + 3
+	a.drawHeaderBackground()
 
 	currStepIdx := max(a.replay.rInfo.currStepIdx, 0)
-	currTimeStr := strconv.Itoa(int(a.replay.gData.History[currStepIdx].CurrGameDuration.Seconds()))
-	gameDurationStr := strconv.Itoa(int(a.replay.gData.History[len(a.replay.gData.History)-1].CurrGameDuration.Seconds()))
-	timeStr := "Time:" + currTimeStr + "/" + gameDurationStr
-	a.setContentString(currStart, 0, a.defStyle, timeStr)
-	currStart += len(timeStr) + 3
-
-	widthStr := strconv.Itoa(a.replay.gInfo.FieldWidth)
-	heightStr := strconv.Itoa(a.replay.gInfo.FieldHeight)
-	mineCountStr := strconv.Itoa(a.replay.gInfo.MineCount)
-	minesLeftStr := strconv.Itoa(minesLeft(a.replay.gData.Field))
-	fieldInfoStr := widthStr + "x" + heightStr + "(" + minesLeftStr + "/" + mineCountStr + ")"
-	a.setContentString(currStart, 0, a.defStyle, fieldInfoStr)
-	currStart += len(fieldInfoStr) + 3
-
-	currStepStr := strconv.Itoa(a.replay.rInfo.currStepIdx + 1)
-	maxStepStr := strconv.Itoa(len(a.replay.gData.History))
-	stepStr := "Step:" + currStepStr + "/" + maxStepStr
-	a.setContentString(currStart, 0, a.defStyle, stepStr)
-	currStart += len(stepStr) + 3
-
-	dateStr := a.replay.gInfo.CreatedAt.Format("2006-01-02 15:04:05")
-	a.setContentString(currStart, 0, a.defStyle, dateStr)
-	currStart += len(dateStr) + 3
+	history := a.replay.gData.History
+	solved3BV, total3BV := a.statusLine3BV(&a.replay.rInfo.field3BV, a.replay.gData.Field)
+	info := statusInfo{
+		replay:        true,
+		result:        a.replay.gInfo.Result,
+		starred:       a.replay.gInfo.Starred,
+		duration:      history[len(history)-1].CurrGameDuration,
+		time:          history[currStepIdx].CurrGameDuration,
+		timerDecimals: a.settings.TimerDecimals,
+		width:         a.replay.gInfo.FieldWidth,
+		height:        a.replay.gInfo.FieldHeight,
+		mineCount:     a.replay.gInfo.MineCount,
+		minesLeft:     minesLeft(a.replay.gData.Field),
+		cursorX:       a.replay.rInfo.currX,
+		cursorY:       a.replay.rInfo.currY,
+		solved3BV:     solved3BV,
+		total3BV:      total3BV,
+		seed:          a.replay.gInfo.Seed,
+		step:          a.replay.rInfo.currStepIdx + 1,
+		steps:         len(history),
+		progress:      a.replayProgressBar() + " " + formatReplaySpeed(replaySpeeds[a.replay.rInfo.speedIdx]),
+		createdAt:     a.replay.gInfo.CreatedAt,
+	}
+
+	// what the game was annotated with isn't a segment, it is always shown last
+	var extra []string
+	extra = append(extra, a.replay.rInfo.message)
+	if len(a.replay.gInfo.Tags) > 0 {
+		extra = append(extra, "#"+strings.Join(a.replay.gInfo.Tags, " #"))
+	}
+	if a.replay.gInfo.Note != "" {
+		extra = append(extra, "Note:"+a.replay.gInfo.Note)
+	}
+	a.drawStatusLine(info, extra...)
+
+	if a.replay.rInfo.inputActive() {
+		a.drawReplayInput()
+	}
+
+	a.drawReplayTimeline()
+
+	if a.overview.active {
+		a.drawOverview(a.replay.gData.Field)
+		return
+	}
 
 	a.drawField(a.replay.rInfo.currX, a.replay.rInfo.currY, a.replay.rInfo.currScrollX, a.replay.rInfo.currScrollY, a.replay.gData.Field)
+	a.drawMinimap(a.replay.gData.Field, a.replay.rInfo.currScrollX, a.replay.rInfo.currScrollY)
 }
 
 func (a *app) eventKeyReplay(ev *tcell.EventKey) {
 	key := ev.Key()
 	rune := ev.Rune()
 
+	// tags and notes can contain any key
+	if a.replay.rInfo.inputActive() {
+		a.eventKeyReplayInput(key, rune)
+		return
+	}
+
+	if a.overview.active {
+		a.eventKeyOverview(key, rune)
+		return
+	}
+
+	if rune == '+' || rune == '=' {
+		a.replay.rInfo.speedIdx = min(a.replay.rInfo.speedIdx+1, len(replaySpeeds)-1)
+	}
+	if rune == '-' {
+		a.replay.rInfo.speedIdx = max(a.replay.rInfo.speedIdx-1, 0)
+	}
+
 	if a.replay.rInfo.autoplayActive {
 		if key == tcell.KeyEscape || rune == 'q' {
 			a.replay.rInfo.autoplayActive = false
@@ -133,7 +217,7 @@ func (a *app) eventKeyReplay(ev *tcell.EventKey) {
 			close(a.replay.rInfo.stopAutoplay)
 
 			a.play = createPlay(a.replay.gInfo.FieldWidth, a.replay.gInfo.FieldHeight, a.replay.gInfo.MineCount)
-			a.state = "PLAY"
+			a.setState("PLAY")
 		}
 
 		if rune == 'b' {
@@ -141,25 +225,17 @@ func (a *app) eventKeyReplay(ev *tcell.EventKey) {
 			close(a.replay.rInfo.stopAutoplay)
 
 			if a.menu.menuState == "SAVED_GAMES" && a.menu.savedGamesState == "FIND" {
-				savedGames, err := a.loadGameInfos(
-					a.menu.savedGamesPrepareSortByState,
-					a.menu.savedGamesPrepareFieldState == "ALL",
-					a.menu.savedGamesPrepareFieldCustomWidth,
-					a.menu.savedGamesPrepareFieldCustomHeight,
-					a.menu.savedGamesPrepareFieldCustomMineCount,
-				)
+				err := a.reloadSavedGames()
 				if err != nil {
 					a.log(err)
 					a.cancel()
 					return
 				}
-
-				a.menu.savedGames = savedGames
 				a.menu.savedGamesFindCurr = 0
 				a.menu.savedGamesFindScreenOffset = 0
 			}
 
-			a.state = "MENU"
+			a.setState("MENU")
 		}
 
 		return
@@ -180,28 +256,50 @@ func (a *app) eventKeyReplay(ev *tcell.EventKey) {
 
 			}
 
-			savedGames, err := a.loadGameInfos(
-				a.menu.savedGamesPrepareSortByState,
-				a.menu.savedGamesPrepareFieldState == "ALL",
-				a.menu.savedGamesPrepareFieldCustomWidth,
-				a.menu.savedGamesPrepareFieldCustomHeight,
-				a.menu.savedGamesPrepareFieldCustomMineCount,
-			)
+			err = a.reloadSavedGames()
 			if err != nil {
 				a.log(err)
 				a.cancel()
 				return
 			}
-
-			a.menu.savedGames = savedGames
 			a.menu.savedGamesFindCurr = 0
 			a.menu.savedGamesFindScreenOffset = 0
-			a.state = "MENU"
+			a.setState("MENU")
 		} else {
 			a.replay.rInfo.lastMPress = time.Now()
 		}
 	}
 
+	if rune == 'e' {
+		basePath, err := a.exportReplayStep()
+		if err != nil {
+			a.log(err)
+			a.replay.rInfo.message = "Export failed"
+		} else {
+			a.replay.rInfo.message = "Exported:" + basePath + ".{png,svg}"
+		}
+	}
+
+	if rune == '*' {
+		a.toggleReplayStar()
+	}
+
+	if rune == 't' {
+		a.startReplayInput("TAGS")
+	}
+
+	if rune == 'c' {
+		a.startReplayInput("NOTE")
+	}
+
+	if rune == 'E' {
+		a.startReplayRecording("CAST")
+	}
+
+	if rune == 'G' {
+		a.startReplayRecording("GIF")
+	}
+
 	if rune == 'p' {
 		a.replay.rInfo.stopAutoplay = make(chan struct{})
 		a.replay.rInfo.autoplayActive = true
@@ -214,7 +312,7 @@ func (a *app) eventKeyReplay(ev *tcell.EventKey) {
 			a.replay.rInfo.currStepIdx = -1
 		}
 
-		a.replay.rInfo.currX, a.replay.rInfo.currY, a.replay.rInfo.currScrollX, a.replay.rInfo.currScrollY = a.stepsFromZeroTo(a.replay.gData.Field, a.replay.gData.History, a.replay.rInfo.currStepIdx)
+		a.seekReplay(a.replay.rInfo.currStepIdx)
 	}
 
 	if rune == 'h' || key == tcell.KeyLeft {
@@ -223,15 +321,61 @@ func (a *app) eventKeyReplay(ev *tcell.EventKey) {
 			a.replay.rInfo.currStepIdx = len(a.replay.gData.History) - 1
 		}
 
-		a.replay.rInfo.currX, a.replay.rInfo.currY, a.replay.rInfo.currScrollX, a.replay.rInfo.currScrollY = a.stepsFromZeroTo(a.replay.gData.Field, a.replay.gData.History, a.replay.rInfo.currStepIdx)
+		a.seekReplay(a.replay.rInfo.currStepIdx)
 	}
 	if rune == 'j' || key == tcell.KeyDown {
 		a.replay.rInfo.currStepIdx = -1
-		a.replay.rInfo.currX, a.replay.rInfo.currY, a.replay.rInfo.currScrollX, a.replay.rInfo.currScrollY = a.stepsFromZeroTo(a.replay.gData.Field, a.replay.gData.History, a.replay.rInfo.currStepIdx)
+		a.seekReplay(a.replay.rInfo.currStepIdx)
 	}
 	if rune == 'k' || key == tcell.KeyUp {
 		a.replay.rInfo.currStepIdx = len(a.replay.gData.History) - 1
-		a.replay.rInfo.currX, a.replay.rInfo.currY, a.replay.rInfo.currScrollX, a.replay.rInfo.currScrollY = a.stepsFromZeroTo(a.replay.gData.Field, a.replay.gData.History, a.replay.rInfo.currStepIdx)
+		a.seekReplay(a.replay.rInfo.currStepIdx)
+	}
+
+	if rune == 'H' {
+		a.seekReplayTime(a.replayCurrTime() - REPLAY_SEEK_TIME)
+	}
+	if rune == 'L' {
+		a.seekReplayTime(a.replayCurrTime() + REPLAY_SEEK_TIME)
+	}
+
+	switch rune {
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		tenths := time.Duration(rune - '0')
+		a.seekReplayTime(a.replay.gData.History[len(a.replay.gData.History)-1].CurrGameDuration * tenths / 10)
+	}
+
+	if rune == 'M' {
+		a.toggleMinimap()
+	}
+
+	if rune == 'z' {
+		a.openOverview(a.replay.gData.Field, a.replay.rInfo.currX, a.replay.rInfo.currY)
+	}
+
+	if rune == '[' {
+		a.seekReplayTimelineBy(-1)
+	}
+	if rune == ']' {
+		a.seekReplayTimelineBy(1)
+	}
+
+	isInteresting := func(step historyStep) bool { return step.Kind != "MOVE" }
+	isOpen := func(step historyStep) bool { return step.Kind == "OPEN" }
+	isFlag := func(step historyStep) bool { return step.Kind == "FLAG" }
+	switch rune {
+	case 'n':
+		a.seekReplayFind(1, isInteresting)
+	case 'N':
+		a.seekReplayFind(-1, isInteresting)
+	case '.':
+		a.seekReplayFind(1, isOpen)
+	case ',':
+		a.seekReplayFind(-1, isOpen)
+	case '>':
+		a.seekReplayFind(1, isFlag)
+	case '<':
+		a.seekReplayFind(-1, isFlag)
 	}
 
 	if rune == 'i' {
@@ -267,27 +411,19 @@ func (a *app) eventKeyReplay(ev *tcell.EventKey) {
 	switch rune {
 	case 'r':
 		a.play = createPlay(a.replay.gInfo.FieldWidth, a.replay.gInfo.FieldHeight, a.replay.gInfo.MineCount)
-		a.state = "PLAY"
+		a.setState("PLAY")
 	case 'b':
 		if a.menu.menuState == "SAVED_GAMES" && a.menu.savedGamesState == "FIND" {
-			savedGames, err := a.loadGameInfos(
-				a.menu.savedGamesPrepareSortByState,
-				a.menu.savedGamesPrepareFieldState == "ALL",
-				a.menu.savedGamesPrepareFieldCustomWidth,
-				a.menu.savedGamesPrepareFieldCustomHeight,
-				a.menu.savedGamesPrepareFieldCustomMineCount,
-			)
+			err := a.reloadSavedGames()
 			if err != nil {
 				a.log(err)
 				a.cancel()
 				return
 			}
-
-			a.menu.savedGames = savedGames
 			a.menu.savedGamesFindCurr = 0
 			a.menu.savedGamesFindScreenOffset = 0
 		}
-		a.state = "MENU"
+		a.setState("MENU")
 	}
 }
 
@@ -314,7 +450,8 @@ func (a *app) autoplayReplay() {
 		default:
 			var timer *time.Timer
 			if step > 0 {
-				timer = time.NewTimer((a.replay.gData.History[step].CurrGameDuration - a.replay.gData.History[step-1].CurrGameDuration).Abs())
+				delay := (a.replay.gData.History[step].CurrGameDuration - a.replay.gData.History[step-1].CurrGameDuration).Abs()
+				timer = time.NewTimer(time.Duration(float64(delay) / replaySpeeds[a.replay.rInfo.speedIdx]))
 			} else {
 				timer = time.NewTimer(time.Duration(0))
 			}
@@ -326,7 +463,7 @@ func (a *app) autoplayReplay() {
 				return
 			case <-timer.C:
 				a.replay.rInfo.currStepIdx = step
-				a.replay.rInfo.currX, a.replay.rInfo.currY, a.replay.rInfo.currScrollX, a.replay.rInfo.currScrollY = a.stepsFromZeroTo(a.replay.gData.Field, a.replay.gData.History, a.replay.rInfo.currStepIdx)
+				a.seekReplay(a.replay.rInfo.currStepIdx)
 
 				a.screen.Clear()
 				a.draw()
@@ -338,27 +475,80 @@ func (a *app) autoplayReplay() {
 	a.replay.rInfo.autoplayActive = false
 }
 
-// modifies field
-func (a *app) stepsFromZeroTo(field [][]fieldCell, history []historyStep, idx int) (x int, y int, scrollX int, scrollY int) {
-	closeField(field)
-	for i := 0; i <= idx; i++ {
-		x, y, scrollX, scrollY = a.nextStep(field, x, y, scrollX, scrollY, history[i])
+// moves the replay field and cursor to step idx, keeping the scroll
+// unless the cursor would leave the screen
+func (a *app) seekReplay(idx int) {
+	err := a.replay.rInfo.steps.seek(idx)
+	if err != nil {
+		a.log(err)
+		a.cancel()
+		return
+	}
+
+	a.replay.rInfo.currStepIdx = idx
+	a.replay.rInfo.field3BV = cached3BV{}
+	a.replay.rInfo.currX, a.replay.rInfo.currY = a.replay.rInfo.steps.position()
+	a.replay.rInfo.currScrollX, a.replay.rInfo.currScrollY = a.alignField(a.replay.rInfo.currX, a.replay.rInfo.currY, a.replay.rInfo.currScrollX, a.replay.rInfo.currScrollY)
+}
+
+func (a *app) replayCurrTime() time.Duration {
+	if a.replay.rInfo.currStepIdx < 0 {
+		return 0
+	}
+
+	return a.replay.gData.History[a.replay.rInfo.currStepIdx].CurrGameDuration
+}
+
+// seeks to the last step that happened at or before t
+func (a *app) seekReplayTime(t time.Duration) {
+	history := a.replay.gData.History
+	idx := sort.Search(len(history), func(i int) bool {
+		return history[i].CurrGameDuration > t
+	}) - 1
+
+	a.seekReplay(idx)
+}
+
+// seeks to the closest step in direction (1 or -1) for which match is true,
+// stays on the current step if there is none
+func (a *app) seekReplayFind(direction int, match func(historyStep) bool) {
+	history := a.replay.gData.History
+	for idx := a.replay.rInfo.currStepIdx + direction; idx >= 0 && idx < len(history); idx += direction {
+		if match(history[idx]) {
+			a.seekReplay(idx)
+			return
+		}
 	}
+}
 
-	return
+func (a *app) replayProgressBar() string {
+	history := a.replay.gData.History
+	total := history[len(history)-1].CurrGameDuration
+
+	filled := REPLAY_PROGRESS_WIDTH
+	if total > 0 {
+		filled = int(int64(REPLAY_PROGRESS_WIDTH) * int64(a.replayCurrTime()) / int64(total))
+	}
+	if a.replay.rInfo.currStepIdx < 0 {
+		filled = 0
+	}
+
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", REPLAY_PROGRESS_WIDTH-filled) + "]"
+}
+
+func formatReplaySpeed(speed float64) string {
+	return strconv.FormatFloat(speed, 'f', -1, 64) + "x"
 }
 
 // modifies field
-func (a *app) nextStep(field [][]fieldCell, fieldCurrX, fieldCurrY, fieldCurrScrollX, fieldCurrScrollY int, step historyStep) (x int, y int, scrollX int, scrollY int) {
+func nextStep(field [][]fieldCell, fieldCurrX, fieldCurrY int, step historyStep) (x int, y int, err error) {
 	switch step.Kind {
 	case "MOVE":
-		fieldCurrScrollX, fieldCurrScrollY = a.alignField(step.MoveX, step.MoveY, fieldCurrScrollX, fieldCurrScrollY)
-		return step.MoveX, step.MoveY, fieldCurrScrollX, fieldCurrScrollY
+		return step.MoveX, step.MoveY, nil
 	case "OPEN":
 		result := openField(field, fieldCurrX, fieldCurrY)
 		if result != step.OpenResult {
-			a.log("ERROR: result is not equal to step.OpenResult. This should never happen!!!")
-			a.cancel()
+			return fieldCurrX, fieldCurrY, fmt.Errorf("ERROR: result is not equal to step.OpenResult. This should never happen!!!")
 		}
 
 		if step.OpenResult != "NONE" {
@@ -368,5 +558,5 @@ func (a *app) nextStep(field [][]fieldCell, fieldCurrX, fieldCurrY, fieldCurrScr
 		flagField(field, fieldCurrX, fieldCurrY)
 	}
 
-	return fieldCurrX, fieldCurrY, fieldCurrScrollX, fieldCurrScrollY
+	return fieldCurrX, fieldCurrY, nil
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// comma separated, surrounding spaces are dropped and every tag is kept once
+func parseTags(str string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(str, ",") {
+		// 0 ends a tag in IndexTag keys
+		tag = strings.TrimSpace(strings.ReplaceAll(tag, "\x00", ""))
+		if tag == "" || slices.Contains(tags, tag) {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+func (a *app) toggleReplayStar() {
+	gInfo := a.replay.gInfo
+	gInfo.Starred = !gInfo.Starred
+	a.saveReplayGameInfo(gInfo)
+}
+
+// starts editing tags or the note, the input starts with the current value
+func (a *app) startReplayInput(inputState string) {
+	a.replay.rInfo.inputState = inputState
+	switch inputState {
+	case "TAGS":
+		a.replay.rInfo.input = strings.Join(a.replay.gInfo.Tags, ", ")
+	case "NOTE":
+		a.replay.rInfo.input = a.replay.gInfo.Note
+	}
+}
+
+func (a *app) eventKeyReplayInput(key tcell.Key, rune rune) {
+	switch key {
+	case tcell.KeyEscape:
+		a.replay.rInfo.inputState = "NONE"
+	case tcell.KeyEnter:
+		gInfo := a.replay.gInfo
+		switch a.replay.rInfo.inputState {
+		case "TAGS":
+			gInfo.Tags = parseTags(a.replay.rInfo.input)
+		case "NOTE":
+			gInfo.Note = strings.TrimSpace(a.replay.rInfo.input)
+		}
+		a.replay.rInfo.inputState = "NONE"
+		a.saveReplayGameInfo(gInfo)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		_, size := utf8.DecodeLastRuneInString(a.replay.rInfo.input)
+		a.replay.rInfo.input = a.replay.rInfo.input[:len(a.replay.rInfo.input)-size]
+	case tcell.KeyRune:
+		a.replay.rInfo.input += string(rune)
+	}
+}
+
+// a game that is still being saved or was deleted can't be changed
+func (a *app) saveReplayGameInfo(gInfo gameInfo) {
+	err := a.updateGameInfo(gInfo)
+	if err != nil {
+		a.log(err)
+		a.replay.rInfo.message = "Saving failed"
+		return
+	}
+
+	a.replay.gInfo = gInfo
+	a.replay.rInfo.message = ""
+}
+
+// drawn over the header while tags or the note are edited
+func (a *app) drawReplayInput() {
+	width, _ := a.screen.Size()
+	for x := range width {
+		a.screen.SetContent(x, 0, ' ', nil, a.defStyle)
+	}
+
+	promptStr := ""
+	switch a.replay.rInfo.inputState {
+	case "TAGS":
+		promptStr = "Tags(comma separated):"
+	case "NOTE":
+		promptStr = "Note:"
+	}
+	promptStr += a.replay.rInfo.input
+
+	a.setContentString(0, 0, a.defStyle, promptStr)
+	a.screen.SetContent(runewidth.StringWidth(promptStr), 0, ' ', nil, a.defStyle.Reverse(true))
+}
@@ -0,0 +1,182 @@
+package main
+
+type cellChange struct {
+	X    int
+	Y    int
+	From int
+	To   int
+}
+
+type stepPosition struct {
+	X int
+	Y int
+}
+
+// Field of a replay at any step, kept as per-step diffs so seeking costs
+// the cells changed between the two steps instead of re-simulating from
+// step zero. Steps are simulated lazily the first time they are reached.
+type replaySteps struct {
+	field   [][]fieldCell
+	history []historyStep
+
+	// index of the step field currently shows, -1 is before the first step
+	curr int
+	// diffs and positions are known for steps up to and including computed
+	computed  int
+	diffs     [][]cellChange
+	positions []stepPosition
+
+	// states of field at computed, used to find which cells a step changed
+	computedStates [][]int
+}
+
+// modifies field
+func createReplaySteps(field [][]fieldCell, history []historyStep) *replaySteps {
+	closeField(field)
+
+	computedStates := make([][]int, len(field))
+	for y := range field {
+		computedStates[y] = make([]int, len(field[y]))
+	}
+
+	return &replaySteps{
+		field:   field,
+		history: history,
+
+		curr:      -1,
+		computed:  -1,
+		diffs:     make([][]cellChange, len(history)),
+		positions: make([]stepPosition, len(history)),
+
+		computedStates: computedStates,
+	}
+}
+
+func (r *replaySteps) seek(idx int) error {
+	idx = max(min(idx, len(r.history)-1), -1)
+
+	for r.curr < idx {
+		if r.curr == r.computed {
+			err := r.computeNext()
+			if err != nil {
+				return err
+			}
+		} else {
+			for _, c := range r.diffs[r.curr+1] {
+				r.field[c.Y][c.X].State = c.To
+			}
+		}
+		r.curr++
+	}
+
+	for r.curr > idx {
+		for _, c := range r.diffs[r.curr] {
+			r.field[c.Y][c.X].State = c.From
+		}
+		r.curr--
+	}
+
+	return nil
+}
+
+func (r *replaySteps) position() (x int, y int) {
+	if r.curr < 0 {
+		return 0, 0
+	}
+
+	return r.positions[r.curr].X, r.positions[r.curr].Y
+}
+
+// field must be at computed
+func (r *replaySteps) computeNext() error {
+	x, y := 0, 0
+	if r.computed >= 0 {
+		x, y = r.positions[r.computed].X, r.positions[r.computed].Y
+	}
+
+	idx := r.computed + 1
+	step := r.history[idx]
+
+	x, y, err := nextStep(r.field, x, y, step)
+	if err != nil {
+		return err
+	}
+
+	var changes []cellChange
+	switch {
+	case step.Kind == "OPEN" && step.OpenResult != "NONE":
+		// every mine gets opened, so there is no telling where changes are
+		changes = r.collectAllChanges()
+	case step.Kind == "OPEN":
+		changes = r.collectChangesAround(x, y)
+	case step.Kind == "FLAG":
+		changes = r.collectChangesAt(x, y, nil)
+	}
+
+	r.diffs[idx] = changes
+	r.positions[idx] = stepPosition{X: x, Y: y}
+	r.computed = idx
+
+	return nil
+}
+
+func (r *replaySteps) collectChangesAt(x, y int, changes []cellChange) []cellChange {
+	from := r.computedStates[y][x]
+	to := r.field[y][x].State
+	if from == to {
+		return changes
+	}
+
+	r.computedStates[y][x] = to
+	return append(changes, cellChange{X: x, Y: y, From: from, To: to})
+}
+
+// Every cell an open changes is connected to the opened cell through other
+// changed cells, except the ones opened around an already open zero next to
+// it, which is why the search starts from two cells around it.
+func (r *replaySteps) collectChangesAround(x, y int) []cellChange {
+	var changes []cellChange
+	queue := []stepPosition{}
+
+	for cy := -2; cy <= 2; cy++ {
+		for cx := -2; cx <= 2; cx++ {
+			queue = append(queue, stepPosition{X: x + cx, Y: y + cy})
+		}
+	}
+
+	for len(queue) > 0 {
+		p := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		if p.Y < 0 || p.Y >= len(r.field) || p.X < 0 || p.X >= len(r.field[0]) {
+			continue
+		}
+
+		changesLen := len(changes)
+		changes = r.collectChangesAt(p.X, p.Y, changes)
+		if len(changes) == changesLen {
+			continue
+		}
+
+		for cy := -1; cy <= 1; cy++ {
+			for cx := -1; cx <= 1; cx++ {
+				if cx != 0 || cy != 0 {
+					queue = append(queue, stepPosition{X: p.X + cx, Y: p.Y + cy})
+				}
+			}
+		}
+	}
+
+	return changes
+}
+
+func (r *replaySteps) collectAllChanges() []cellChange {
+	var changes []cellChange
+	for y := range r.field {
+		for x := range r.field[y] {
+			changes = r.collectChangesAt(x, y, changes)
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+// A game of moves, flags on mines and opens of safe cells picked at random,
+// lost on a mine after opens steps when it isn't won before then.
+func syntheticGame(width, height, mineCount, opens int, seed uint64) gameData {
+	field := createField(width, height, mineCount, seed)
+	played := closeFieldCopy(field)
+	r := rand.New(rand.NewPCG(seed, seed))
+
+	var history []historyStep
+	var duration time.Duration
+	addStep := func(step historyStep) {
+		duration += time.Duration(r.IntN(500)) * time.Millisecond
+		step.CurrGameDuration = duration
+		history = append(history, step)
+	}
+
+	for range opens {
+		x, y := r.IntN(width), r.IntN(height)
+		addStep(historyStep{Kind: "MOVE", MoveX: x, MoveY: y})
+
+		if field[y][x].Value == CELL_VALUE_MINE {
+			flagField(played, x, y)
+			addStep(historyStep{Kind: "FLAG"})
+			continue
+		}
+
+		result := openField(played, x, y)
+		addStep(historyStep{Kind: "OPEN", OpenResult: result})
+		if result != "NONE" {
+			return gameData{Field: field, History: history}
+		}
+	}
+
+	for y := range field {
+		for x := range field[y] {
+			if field[y][x].Value == CELL_VALUE_MINE && played[y][x].State == CELL_STATE_HIDDEN {
+				addStep(historyStep{Kind: "MOVE", MoveX: x, MoveY: y})
+				addStep(historyStep{Kind: "OPEN", OpenResult: "LOST"})
+				return gameData{Field: field, History: history}
+			}
+		}
+	}
+
+	return gameData{Field: field, History: history}
+}
+
+// the field replayed with nextStep from the first step up to and including idx
+func replayFieldTo(field [][]fieldCell, history []historyStep, idx int) ([][]fieldCell, int, int, error) {
+	replayed := closeFieldCopy(field)
+	var x, y int
+	for _, step := range history[:idx+1] {
+		var err error
+		x, y, err = nextStep(replayed, x, y, step)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	return replayed, x, y, nil
+}
+
+func TestReplayStepsSeek(t *testing.T) {
+	gData := syntheticGame(40, 30, 200, 400, 1)
+	field := closeFieldCopy(gData.Field)
+	steps := createReplaySteps(field, gData.History)
+
+	r := rand.New(rand.NewPCG(2, 2))
+	targets := []int{len(gData.History) - 1, -1, len(gData.History) - 1, 0}
+	for range 50 {
+		targets = append(targets, r.IntN(len(gData.History)+1)-1)
+	}
+
+	for _, idx := range targets {
+		err := steps.seek(idx)
+		if err != nil {
+			t.Fatalf("seek(%d): %v", idx, err)
+		}
+
+		want, wantX, wantY, err := replayFieldTo(gData.Field, gData.History, idx)
+		if err != nil {
+			t.Fatalf("replaying to %d: %v", idx, err)
+		}
+
+		for y := range want {
+			if !slices.Equal(field[y], want[y]) {
+				t.Fatalf("seek(%d): row %d is %v, replaying from zero gives %v", idx, y, field[y], want[y])
+			}
+		}
+
+		x, y := steps.position()
+		if x != wantX || y != wantY {
+			t.Fatalf("seek(%d): position is %d,%d, replaying from zero gives %d,%d", idx, x, y, wantX, wantY)
+		}
+	}
+}
+
+// Seeks to the end, back to before the first step, to random steps and
+// one step at a time like holding h, next to the same seeks replayed from
+// zero. Steps are computed once up front as opening a replay does.
+func BenchmarkReplaySeek(b *testing.B) {
+	gData := syntheticGame(500, 500, 50000, 20000, 1)
+	last := len(gData.History) - 1
+	r := rand.New(rand.NewPCG(2, 2))
+	targets := []int{last, -1, r.IntN(last + 1), r.IntN(last + 1), r.IntN(last + 1)}
+	for idx := last; idx > last-20; idx-- {
+		targets = append(targets, idx)
+	}
+
+	b.Run("diffs", func(b *testing.B) {
+		steps := createReplaySteps(closeFieldCopy(gData.Field), gData.History)
+		err := steps.seek(last)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for b.Loop() {
+			for _, idx := range targets {
+				err := steps.seek(idx)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("fromZero", func(b *testing.B) {
+		for b.Loop() {
+			for _, idx := range targets {
+				_, _, _, err := replayFieldTo(gData.Field, gData.History, idx)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
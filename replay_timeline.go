@@ -0,0 +1,149 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const REPLAY_TIMELINE_Y int = 1
+
+var timelineDensityRunes = []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+
+// one line under the header, every column is an equal slice of the game's
+// duration showing how many opens and flags happened in it
+func (a *app) drawReplayTimeline() {
+	width, _ := a.screen.Size()
+	if width <= 0 {
+		return
+	}
+
+	history := a.replay.gData.History
+	opens := make([]int, width)
+	flags := make([]int, width)
+	for _, step := range history {
+		col := a.replayTimelineColumn(step.CurrGameDuration, width)
+		switch step.Kind {
+		case "OPEN":
+			opens[col]++
+		case "FLAG":
+			flags[col]++
+		}
+	}
+
+	maxEvents := 0
+	for col := range width {
+		maxEvents = max(maxEvents, opens[col]+flags[col])
+	}
+
+	_, flagStyle := a.cellToStyle(fieldCell{State: CELL_STATE_FLAG})
+	_, mineStyle := a.cellToStyle(fieldCell{Value: CELL_VALUE_MINE, State: CELL_STATE_OPEN})
+
+	for col := range width {
+		rune := '─'
+		style := a.defStyle
+
+		events := opens[col] + flags[col]
+		if events > 0 {
+			level := (events*len(timelineDensityRunes) - 1) / maxEvents
+			rune = timelineDensityRunes[level]
+		}
+		if flags[col] > 0 {
+			style = flagStyle
+		}
+
+		a.screen.SetContent(col, REPLAY_TIMELINE_Y, rune, nil, style)
+	}
+
+	switch a.replay.gInfo.Result {
+	case "WON":
+		a.screen.SetContent(width-1, REPLAY_TIMELINE_Y, 'W', nil, a.defStyle)
+	case "LOST":
+		a.screen.SetContent(width-1, REPLAY_TIMELINE_Y, 'L', nil, mineStyle)
+	}
+
+	cursorCol := 0
+	if a.replay.rInfo.currStepIdx >= 0 {
+		cursorCol = a.replayTimelineColumn(a.replayCurrTime(), width)
+	}
+	mainc, combc, style, _ := a.screen.GetContent(cursorCol, REPLAY_TIMELINE_Y)
+	a.screen.SetContent(cursorCol, REPLAY_TIMELINE_Y, mainc, combc, style.Reverse(true))
+}
+
+func (a *app) replayTimelineColumn(t time.Duration, width int) int {
+	total := a.replay.gData.History[len(a.replay.gData.History)-1].CurrGameDuration
+	if total <= 0 {
+		return 0
+	}
+
+	col := int(int64(t) * int64(width) / int64(total))
+	return max(min(col, width-1), 0)
+}
+
+// seeks to the step closest to the middle of column col
+func (a *app) seekReplayTimelineColumn(col int) {
+	width, _ := a.screen.Size()
+	if width <= 0 {
+		return
+	}
+	col = max(min(col, width-1), 0)
+
+	total := a.replay.gData.History[len(a.replay.gData.History)-1].CurrGameDuration
+	t := time.Duration((int64(col)*2 + 1) * int64(total) / int64(width*2))
+
+	a.seekReplay(a.nearestReplayStep(t))
+}
+
+func (a *app) nearestReplayStep(t time.Duration) int {
+	history := a.replay.gData.History
+	idx := sort.Search(len(history), func(i int) bool {
+		return history[i].CurrGameDuration >= t
+	})
+
+	if idx >= len(history) {
+		return len(history) - 1
+	}
+	if idx > 0 && t-history[idx-1].CurrGameDuration <= history[idx].CurrGameDuration-t {
+		return idx - 1
+	}
+
+	return idx
+}
+
+// moves over the timeline by whole columns, skipping columns without steps
+func (a *app) seekReplayTimelineBy(direction int) {
+	width, _ := a.screen.Size()
+	if width <= 0 {
+		return
+	}
+
+	history := a.replay.gData.History
+	currCol := 0
+	if a.replay.rInfo.currStepIdx >= 0 {
+		currCol = a.replayTimelineColumn(a.replayCurrTime(), width)
+	}
+
+	for idx := a.replay.rInfo.currStepIdx + direction; idx >= 0 && idx < len(history); idx += direction {
+		if a.replayTimelineColumn(history[idx].CurrGameDuration, width) != currCol {
+			a.seekReplay(idx)
+			return
+		}
+	}
+}
+
+func (a *app) eventMouseReplay(ev *tcell.EventMouse) {
+	if a.replay.rInfo.autoplayActive {
+		return
+	}
+
+	if a.overview.active {
+		a.eventMouseOverview(ev)
+		return
+	}
+
+	x, y := ev.Position()
+	if y == REPLAY_TIMELINE_Y && ev.Buttons()&tcell.Button1 != 0 {
+		a.seekReplayTimelineColumn(x)
+	}
+}
@@ -1,232 +1,276 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
 	"slices"
+	"time"
 
 	"github.com/google/uuid"
-	bolt "go.etcd.io/bbolt"
 )
 
-func (a *app) saveGame(gInfo gameInfo, gData gameData) error {
-	a.wg.Add(1)
-	defer a.wg.Done()
+// how many saved games are decoded at once when the FIND list needs rows
+const SAVED_GAMES_PAGE_SIZE int = 64
+
+// Zero values of bounds mean unbounded.
+type gameQuery struct {
+	// LATEST, OLDEST, BEST, WORST, STARRED
+	SortBy string
+	// when false only games with exactly this field size and mine count match
+	FieldAll       bool
+	FieldWidth     int
+	FieldHeight    int
+	FieldMineCount int
+
+	// ALL, WON, LOST
+	Result string
+	// from is inclusive, to is exclusive
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// inclusive
+	DurationMin time.Duration
+	DurationMax time.Duration
+	// in percent, inclusive
+	DensityMin float64
+	DensityMax float64
+	WidthMin   int
+	WidthMax   int
+	HeightMin  int
+	HeightMax  int
+
+	StarredOnly bool
+	// matched by the storage, summaries of the indexes don't have tags
+	Tag string
+}
 
-	dataFilePath, err := getDataFilePath()
-	if err != nil {
-		return err
+func (q gameQuery) matches(info gameInfo) bool {
+	if !q.FieldAll {
+		if q.FieldWidth != info.FieldWidth ||
+			q.FieldHeight != info.FieldHeight ||
+			q.FieldMineCount != info.MineCount {
+			return false
+		}
 	}
 
-	db, err := bolt.Open(dataFilePath, 0600, nil)
-	if err != nil {
-		return err
+	if q.Result != "" && q.Result != "ALL" && q.Result != info.Result {
+		return false
 	}
-	defer db.Close()
 
-	err = db.Update(func(tx *bolt.Tx) error {
-		bucketGameInfo, err := tx.CreateBucketIfNotExists([]byte("GameInfo"))
-		if err != nil {
-			return err
-		}
+	if !q.CreatedFrom.IsZero() && info.CreatedAt.Before(q.CreatedFrom) {
+		return false
+	}
+	if !q.CreatedTo.IsZero() && !info.CreatedAt.Before(q.CreatedTo) {
+		return false
+	}
 
-		gobGameInfo, err := toGob(gInfo)
-		if err != nil {
-			return err
-		}
+	if q.DurationMin > 0 && info.GameDuration < q.DurationMin {
+		return false
+	}
+	if q.DurationMax > 0 && info.GameDuration > q.DurationMax {
+		return false
+	}
 
-		err = bucketGameInfo.Put([]byte(gInfo.Id.String()), gobGameInfo)
-		if err != nil {
-			return err
-		}
+	density := getMineDensity(info.FieldWidth, info.FieldHeight, info.MineCount)
+	if q.DensityMin > 0 && density < q.DensityMin {
+		return false
+	}
+	if q.DensityMax > 0 && density > q.DensityMax {
+		return false
+	}
 
-		bucketGameData, err := tx.CreateBucketIfNotExists([]byte("GameData"))
-		if err != nil {
-			return err
-		}
+	if q.WidthMin > 0 && info.FieldWidth < q.WidthMin {
+		return false
+	}
+	if q.WidthMax > 0 && info.FieldWidth > q.WidthMax {
+		return false
+	}
+	if q.HeightMin > 0 && info.FieldHeight < q.HeightMin {
+		return false
+	}
+	if q.HeightMax > 0 && info.FieldHeight > q.HeightMax {
+		return false
+	}
 
-		gobGameData, err := toGob(gData)
-		if err != nil {
-			return err
-		}
+	if q.StarredOnly && !info.Starred {
+		return false
+	}
 
-		return bucketGameData.Put([]byte(gData.Id.String()), gobGameData)
-	})
-	return err
+	return true
 }
 
-func (a *app) loadGameInfoAndData(id uuid.UUID) (gameInfo, gameData, error) {
-	a.wg.Add(1)
-	defer a.wg.Done()
-
-	dataFilePath, err := getDataFilePath()
-	if err != nil {
-		return gameInfo{}, gameData{}, err
+func sortGameInfos(infos []gameInfo, sortBy string) {
+	switch sortBy {
+	case "LATEST":
+		slices.SortStableFunc(infos, func(a, b gameInfo) int {
+			return b.CreatedAt.Compare(a.CreatedAt)
+		})
+	case "OLDEST":
+		slices.SortStableFunc(infos, func(a, b gameInfo) int {
+			return a.CreatedAt.Compare(b.CreatedAt)
+		})
+	case "BEST":
+		slices.SortStableFunc(infos, compareGameInfosBest)
+	case "WORST":
+		slices.SortStableFunc(infos, func(a, b gameInfo) int {
+			return compareGameInfosBest(b, a)
+		})
+	case "STARRED":
+		// starred games first, then the latest
+		slices.SortStableFunc(infos, func(a, b gameInfo) int {
+			if a.Starred != b.Starred {
+				if a.Starred {
+					return -1
+				}
+				return 1
+			}
+			return b.CreatedAt.Compare(a.CreatedAt)
+		})
 	}
+}
 
-	db, err := bolt.Open(dataFilePath, 0600, nil)
-	if err != nil {
-		return gameInfo{}, gameData{}, err
+// won games first, then the fastest
+func compareGameInfosBest(a, b gameInfo) int {
+	if resultRank(a.Result) != resultRank(b.Result) {
+		return resultRank(a.Result) - resultRank(b.Result)
 	}
-	defer db.Close()
-
-	var gInfo gameInfo
-	var gData gameData
-	err = db.View(func(tx *bolt.Tx) error {
-		bucketGameInfo := tx.Bucket([]byte("GameInfo"))
-		if bucketGameInfo == nil {
-			return fmt.Errorf("bucket doesn't exist")
-		}
 
-		gobGInfo := bucketGameInfo.Get([]byte(id.String()))
-		if gobGInfo == nil {
-			return fmt.Errorf("key doesn't exist")
-		}
+	return cmp.Compare(a.GameDuration, b.GameDuration)
+}
 
-		gInfo, err = fromGob[gameInfo](gobGInfo)
-		if err != nil {
-			return err
-		}
+func resultRank(result string) int {
+	switch result {
+	case "WON":
+		return 0
+	case "LOST":
+		return 1
+	}
 
-		bucketGameData := tx.Bucket([]byte("GameData"))
-		if bucketGameData == nil {
-			return fmt.Errorf("bucket doesn't exist")
-		}
+	return 2
+}
 
-		gobGData := bucketGameData.Get([]byte(id.String()))
-		if gobGData == nil {
-			return fmt.Errorf("key doesn't exist")
-		}
+func (a *app) saveGame(gInfo gameInfo, gData gameData) error {
+	a.wg.Add(1)
+	defer a.wg.Done()
 
-		gData, err = fromGob[gameData](gobGData)
-		return err
-	})
-	return gInfo, gData, err
+	return a.store.saveGame(gInfo, gData)
 }
 
-func (a *app) loadGameInfos(sortBy string, fieldAll bool, fieldWidth, fieldHeight, fieldMineCount int) ([]gameInfo, error) {
+func (a *app) loadGameInfoAndData(id uuid.UUID) (gameInfo, gameData, error) {
 	a.wg.Add(1)
 	defer a.wg.Done()
 
-	dataFilePath, err := getDataFilePath()
-	if err != nil {
-		return []gameInfo{}, err
-	}
+	return a.store.loadGameInfoAndData(id)
+}
 
-	db, err := bolt.Open(dataFilePath, 0600, nil)
-	if err != nil {
-		return []gameInfo{}, err
+func (a *app) savedGamesQuery() gameQuery {
+	createdFrom, createdTo := a.savedGamesCreatedRange(time.Now())
+
+	// durations are shown in whole seconds, so the max second is included fully
+	durationMax := time.Duration(0)
+	if a.menu.savedGamesPrepareDurationMax > 0 {
+		durationMax = time.Duration(a.menu.savedGamesPrepareDurationMax+1)*time.Second - 1
 	}
-	defer db.Close()
 
-	var infos []gameInfo
-	err = db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte("GameInfo"))
-		if bucket == nil {
-			return nil
-		}
+	return gameQuery{
+		SortBy:         a.menu.savedGamesPrepareSortByState,
+		FieldAll:       a.menu.savedGamesPrepareFieldState == "ALL",
+		FieldWidth:     a.menu.savedGamesPrepareFieldCustomWidth,
+		FieldHeight:    a.menu.savedGamesPrepareFieldCustomHeight,
+		FieldMineCount: a.menu.savedGamesPrepareFieldCustomMineCount,
+		Result:         a.menu.savedGamesPrepareResultState,
+		CreatedFrom:    createdFrom,
+		CreatedTo:      createdTo,
+		DurationMin:    time.Duration(a.menu.savedGamesPrepareDurationMin) * time.Second,
+		DurationMax:    durationMax,
+		DensityMin:     float64(a.menu.savedGamesPrepareDensityMin),
+		DensityMax:     float64(a.menu.savedGamesPrepareDensityMax),
+		WidthMin:       a.menu.savedGamesPrepareSizeWidthMin,
+		WidthMax:       a.menu.savedGamesPrepareSizeWidthMax,
+		HeightMin:      a.menu.savedGamesPrepareSizeHeightMin,
+		HeightMax:      a.menu.savedGamesPrepareSizeHeightMax,
+		StarredOnly:    a.menu.savedGamesPrepareStarredState == "STARRED",
+		Tag:            a.menu.savedGamesPrepareTag,
+	}
+}
 
-		return bucket.ForEach(func(_, v []byte) error {
-			info, err := fromGob[gameInfo](v)
-			if err != nil {
-				return err
-			}
+// runs the query of the saved games prepare screen, only ids are loaded,
+// rows are decoded when FIND needs them or the search is applied again
+func (a *app) reloadSavedGames() error {
+	a.wg.Add(1)
+	defer a.wg.Done()
 
-			infos = append(infos, info)
-			return nil
-		})
-	})
+	ids, err := a.store.queryGameIds(a.savedGamesQuery())
 	if err != nil {
-		return []gameInfo{}, err
+		return err
 	}
 
-	switch sortBy {
-	case "LATEST":
-		slices.SortFunc(infos, func(a, b gameInfo) int {
-			return b.CreatedAt.Compare(a.CreatedAt)
-		})
-	case "OLDEST":
-		slices.SortFunc(infos, func(a, b gameInfo) int {
-			return a.CreatedAt.Compare(b.CreatedAt)
-		})
-	case "BEST":
-		slices.SortFunc(infos, func(a, b gameInfo) int {
-			if a.Result == "WON" && b.Result == "LOST" {
-				return -1
-			}
-			if a.Result == "LOST" && b.Result == "WON" {
-				return 1
-			}
+	a.menu.savedGameIds = ids
+	a.menu.savedGamesCache = map[uuid.UUID]gameInfo{}
+	return a.searchSavedGames()
+}
 
-			res := int(a.GameDuration.Milliseconds()) - int(b.GameDuration.Milliseconds())
-			return int(res)
-		})
-	case "WORST":
-		slices.SortFunc(infos, func(a, b gameInfo) int {
-			if a.Result == "WON" && b.Result == "LOST" {
-				return -1
-			}
-			if a.Result == "LOST" && b.Result == "WON" {
-				return 1
-			}
+// info of the idx-th game of the FIND list, loading the page it is on
+func (a *app) savedGameInfo(idx int) (gameInfo, error) {
+	id := a.menu.savedGameIds[idx]
+	if info, ok := a.menu.savedGamesCache[id]; ok {
+		return info, nil
+	}
 
-			res := int(a.GameDuration.Milliseconds()) - int(b.GameDuration.Milliseconds())
-			return int(res)
-		})
-		slices.Reverse(infos)
+	a.wg.Add(1)
+	defer a.wg.Done()
+
+	pageStart := idx - idx%SAVED_GAMES_PAGE_SIZE
+	pageEnd := min(pageStart+SAVED_GAMES_PAGE_SIZE, len(a.menu.savedGameIds))
+	infos, err := a.store.loadGameInfos(a.menu.savedGameIds[pageStart:pageEnd])
+	if err != nil {
+		return gameInfo{}, err
 	}
 
-	if fieldAll {
-		return infos, nil
+	for _, info := range infos {
+		a.menu.savedGamesCache[info.Id] = info
 	}
 
-	filteredInfos := []gameInfo{}
-	for _, v := range infos {
-		if fieldWidth == v.FieldWidth &&
-			fieldHeight == v.FieldHeight &&
-			fieldMineCount == v.MineCount {
-			filteredInfos = append(filteredInfos, v)
-		}
+	// the query's ids outlive the games, loadGameInfos skips missing ones
+	info, ok := a.menu.savedGamesCache[id]
+	if !ok {
+		return gameInfo{}, fmt.Errorf("saved game %s doesn't exist", id)
 	}
 
-	return filteredInfos, nil
+	return info, nil
 }
 
+// deleted games go to the trash first
 func (a *app) deleteGame(id uuid.UUID) error {
+	return a.deleteGames([]uuid.UUID{id})
+}
+
+func (a *app) deleteGames(ids []uuid.UUID) error {
 	a.wg.Add(1)
 	defer a.wg.Done()
 
-	dataFilePath, err := getDataFilePath()
-	if err != nil {
-		return err
-	}
+	return a.store.trashGames(ids, time.Now())
+}
 
-	db, err := bolt.Open(dataFilePath, 0600, nil)
+func (a *app) updateGameInfo(gInfo gameInfo) error {
+	a.wg.Add(1)
+	defer a.wg.Done()
+
+	return a.store.updateGameInfo(gInfo)
+}
+
+// tags the prepare screen offers, a chosen tag no game has anymore is reset
+func (a *app) reloadSavedGamesTags() error {
+	a.wg.Add(1)
+	defer a.wg.Done()
+
+	tags, err := a.store.loadTags()
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
-	err = db.Update(func(tx *bolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists([]byte("GameInfo"))
-		if err != nil {
-			return err
-		}
-		if bucket == nil {
-			return nil
-		}
-		err = bucket.Delete([]byte(id.String()))
-		if err != nil {
-			return err
-		}
-
-		bucket, err = tx.CreateBucketIfNotExists([]byte("GameData"))
-		if err != nil {
-			return err
-		}
-		if bucket == nil {
-			return nil
-		}
-		return bucket.Delete([]byte(id.String()))
-	})
-	return err
+	a.menu.savedGamesPrepareTags = tags
+	if !slices.Contains(tags, a.menu.savedGamesPrepareTag) {
+		a.menu.savedGamesPrepareTag = ""
+	}
+	return nil
 }
@@ -1,110 +1,30 @@
 package main
 
-import (
-	"fmt"
-
-	bolt "go.etcd.io/bbolt"
-)
-
 type settings struct {
-	// DEFAULT,LIGHT,DARK,MONO
+	// DEFAULT,LIGHT,DARK,MONO or the key of a user theme
 	Theme        string
 	MaxScrolloff int
-}
-
-func getSettings() (settings, error) {
-	dataFilePath, err := getDataFilePath()
-	if err != nil {
-		return settings{}, err
-	}
-
-	db, err := bolt.Open(dataFilePath, 0600, nil)
-	if err != nil {
-		return settings{}, err
-	}
-	defer db.Close()
-
-	var sett settings
-	err = db.View(func(tx *bolt.Tx) error {
-		bucketSettings := tx.Bucket([]byte("Settings"))
-		if bucketSettings == nil {
-			return fmt.Errorf("bucket doesn't exist")
-		}
-
-		gobSettings := bucketSettings.Get([]byte("ALL"))
-		if gobSettings == nil {
-			return fmt.Errorf("key doesn't exist")
-		}
-
-		sett, err = fromGob[settings](gobSettings)
-		return err
-	})
-	return sett, err
+	// trashed games are purged after this many days, never when 0
+	TrashRetentionDays int
+	// AUTO,TRUECOLOR,256,16,8, AUTO is what the terminal reports
+	ColorMode string
+	// numbers are also told apart by bold, underline and italic
+	DistinctCells bool
+	// ASCII,UNICODE,NERD
+	Glyphs string
+	// columns of a cell, 1 to 3, wider when glyphs are
+	CellWidth int
+	// OFF,CELL,FIVE, grid lines after every cell or every 5 cells
+	Grid string
+	// segments of the header in play and replay, in order
+	StatusLine []string
+	// decimal places of the timer's seconds, 0 to 2
+	TimerDecimals int
 }
 
 func (a *app) updateSettings(sett settings) error {
 	a.wg.Add(1)
 	defer a.wg.Done()
 
-	dataFilePath, err := getDataFilePath()
-	if err != nil {
-		return err
-	}
-
-	db, err := bolt.Open(dataFilePath, 0600, nil)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
-	err = db.Update(func(tx *bolt.Tx) error {
-		bucketSettings, err := tx.CreateBucketIfNotExists([]byte("Settings"))
-		if err != nil {
-			return err
-		}
-
-		gobSettings, err := toGob(sett)
-		if err != nil {
-			return err
-		}
-
-		return bucketSettings.Put([]byte("ALL"), gobSettings)
-	})
-	return err
-}
-
-func initSettings() error {
-	dataFilePath, err := getDataFilePath()
-	if err != nil {
-		return err
-	}
-
-	db, err := bolt.Open(dataFilePath, 0600, nil)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
-	err = db.Update(func(tx *bolt.Tx) error {
-		bucketSettings, err := tx.CreateBucketIfNotExists([]byte("Settings"))
-		if err != nil {
-			return err
-		}
-
-		value := bucketSettings.Get([]byte("ALL"))
-		if value != nil {
-			return nil
-		}
-
-		gobSettings, err := toGob(settings{
-			Theme:        "DEFAULT",
-			MaxScrolloff: 2,
-		})
-		if err != nil {
-			return err
-		}
-
-		return bucketSettings.Put([]byte("ALL"), gobSettings)
-	})
-	return err
+	return a.store.updateSettings(sett)
 }
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// every segment the status line can show, in the order settings lists the unused ones
+var statusSegments = []string{"RESULT", "MINES_LEFT", "TIMER", "CURSOR", "3BV", "DENSITY", "SIZE", "STEP", "PROGRESS", "DATE", "SEED", "CLOCK"}
+
+var statusSegmentNames = map[string]string{
+	"RESULT":     "Result",
+	"MINES_LEFT": "Mines Left",
+	"TIMER":      "Timer",
+	"CURSOR":     "Cursor",
+	"3BV":        "3BV",
+	"DENSITY":    "Density",
+	"SIZE":       "Size",
+	"STEP":       "Step",
+	"PROGRESS":   "Progress",
+	"DATE":       "Date",
+	"SEED":       "Seed",
+	"CLOCK":      "Clock",
+}
+
+const STATUS_SEPARATOR string = "   "
+
+// decimal places the Timer setting can show
+var settingsTimerDecimals = []string{"0", "1", "2"}
+
+// What the status line segments show of a game being played or replayed.
+// Replay only segments are left out of a game being played.
+type statusInfo struct {
+	replay bool
+
+	result  string
+	starred bool
+	// of the replayed game, 0 while playing
+	duration time.Duration
+	time     time.Duration
+	// of the Timer setting, the replayed game's duration always has 3
+	timerDecimals int
+
+	width     int
+	height    int
+	mineCount int
+	minesLeft int
+	cursorX   int
+	cursorY   int
+	solved3BV int
+	total3BV  int
+	seed      uint64
+
+	step      int
+	steps     int
+	progress  string
+	createdAt time.Time
+}
+
+// "" when the segment has nothing to show
+func statusSegmentStr(segment string, info statusInfo) string {
+	switch segment {
+	case "RESULT":
+		if !info.replay {
+			return ""
+		}
+		if info.starred {
+			return info.result + " *"
+		}
+		return info.result
+	case "MINES_LEFT":
+		return "Mines Left:" + strconv.Itoa(info.minesLeft)
+	case "TIMER":
+		if info.replay {
+			return "Time:" + formatTimer(info.time, info.timerDecimals) + "/" + formatFinalTime(info.duration)
+		}
+		return "Time:" + formatTimer(info.time, info.timerDecimals)
+	case "CURSOR":
+		// as : takes them
+		return "Cursor:" + strconv.Itoa(info.cursorX+1) + "," + strconv.Itoa(info.cursorY+1)
+	case "3BV":
+		return "3BV:" + strconv.Itoa(info.solved3BV) + "/" + strconv.Itoa(info.total3BV)
+	case "DENSITY":
+		return fmt.Sprintf("%.2f%%", getMineDensity(info.width, info.height, info.mineCount))
+	case "SIZE":
+		return strconv.Itoa(info.width) + "x" + strconv.Itoa(info.height) + "(" + strconv.Itoa(info.mineCount) + ")"
+	case "STEP":
+		if !info.replay {
+			return ""
+		}
+		return "Step:" + strconv.Itoa(info.step) + "/" + strconv.Itoa(info.steps)
+	case "PROGRESS":
+		return info.progress
+	case "DATE":
+		if !info.replay {
+			return ""
+		}
+		return info.createdAt.Format("2006-01-02 15:04:05")
+	case "SEED":
+		if info.seed == 0 {
+			return ""
+		}
+		return "Seed:" + strconv.FormatUint(info.seed, 16)
+	case "CLOCK":
+		return time.Now().Format("15:04")
+	}
+
+	return ""
+}
+
+// seconds with decimals places, cut rather than rounded like a stopwatch
+func formatTimer(d time.Duration, decimals int) string {
+	return strconv.FormatFloat(d.Truncate(timerInterval(decimals)).Seconds(), 'f', decimals, 64)
+}
+
+// times of finished games are compared to the millisecond
+func formatFinalTime(d time.Duration) string {
+	return formatTimer(d, 3)
+}
+
+// how long the last decimal place of the timer lasts, what play redraws at
+func timerInterval(decimals int) time.Duration {
+	interval := time.Second
+	for range decimals {
+		interval /= 10
+	}
+
+	return interval
+}
+
+// Parts that don't fit in width are left out, a part cut by the edge is
+// shortened with an ellipsis when there is room for some of it.
+func fitStatusLine(parts []string, width int) string {
+	line := ""
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		start := line
+		if line != "" {
+			start += STATUS_SEPARATOR
+		}
+
+		if runewidth.StringWidth(start+part) <= width {
+			line = start + part
+			continue
+		}
+
+		if width-runewidth.StringWidth(start) >= 4 {
+			line = runewidth.Truncate(start+part, width, "…")
+		}
+		break
+	}
+
+	return line
+}
+
+func (a *app) drawStatusLine(info statusInfo, extra ...string) {
+	var parts []string
+	for _, segment := range a.settings.StatusLine {
+		parts = append(parts, statusSegmentStr(segment, info))
+	}
+	parts = append(parts, extra...)
+
+	width, _ := a.screen.Size()
+	a.setContentString(0, 0, a.theme.header, fitStatusLine(parts, width))
+}
+
+// Openings, connected empty cells and the numbers around them, count once
+// and so does every number outside of them. Solved are the open ones.
+func field3BV(field [][]fieldCell) (solved, total int) {
+	covered := make([][]bool, len(field))
+	for y := range field {
+		covered[y] = make([]bool, len(field[y]))
+	}
+
+	for y := range field {
+		for x := range field[y] {
+			if field[y][x].Value != 0 || covered[y][x] {
+				continue
+			}
+
+			total++
+			open := false
+			stack := [][2]int{{x, y}}
+			covered[y][x] = true
+			for len(stack) > 0 {
+				cx, cy := stack[len(stack)-1][0], stack[len(stack)-1][1]
+				stack = stack[:len(stack)-1]
+				// opening an empty cell opens all of them
+				open = open || field[cy][cx].Value == 0 && field[cy][cx].State == CELL_STATE_OPEN
+
+				if field[cy][cx].Value != 0 {
+					continue
+				}
+				for ny := max(cy-1, 0); ny <= min(cy+1, len(field)-1); ny++ {
+					for nx := max(cx-1, 0); nx <= min(cx+1, len(field[ny])-1); nx++ {
+						if !covered[ny][nx] {
+							covered[ny][nx] = true
+							stack = append(stack, [2]int{nx, ny})
+						}
+					}
+				}
+			}
+			if open {
+				solved++
+			}
+		}
+	}
+
+	for y := range field {
+		for x := range field[y] {
+			if covered[y][x] || field[y][x].Value == CELL_VALUE_MINE {
+				continue
+			}
+
+			total++
+			if field[y][x].State == CELL_STATE_OPEN {
+				solved++
+			}
+		}
+	}
+
+	return solved, total
+}
+
+// the 3BV of a field since it last changed, the zero value is counted again
+type cached3BV struct {
+	valid  bool
+	solved int
+	total  int
+}
+
+// the whole field is gone through, so only when the status line shows it and
+// the field changed since the last count
+func (a *app) statusLine3BV(cache *cached3BV, field [][]fieldCell) (solved, total int) {
+	if !slices.Contains(a.settings.StatusLine, "3BV") {
+		return 0, 0
+	}
+
+	if !cache.valid {
+		cache.solved, cache.total = field3BV(field)
+		cache.valid = true
+	}
+	return cache.solved, cache.total
+}
+
+// settings lists the chosen segments in order, then the rest
+func statusLineOptions(chosen []string) []string {
+	options := slices.Clone(chosen)
+	for _, segment := range statusSegments {
+		if !slices.Contains(chosen, segment) {
+			options = append(options, segment)
+		}
+	}
+
+	return options
+}
+
+// chosen segments are shown as they are ordered and the others dimmed after them
+func (a *app) drawMenuStatusLine(y int) {
+	currStart := 0
+	for i, segment := range statusLineOptions(a.menu.settingsStatusLine) {
+		style := a.defStyle
+		if !slices.Contains(a.menu.settingsStatusLine, segment) {
+			style = style.Dim(true)
+		}
+		if i == a.menu.settingsStatusLineCurr {
+			style = style.Reverse(true)
+		}
+
+		label := statusSegmentNames[segment]
+		a.setContentString(currStart, y, style, label)
+		currStart += len(label) + 1
+	}
+}
+
+// x shows or hides the segment under the cursor, H and L move a shown one
+func (a *app) eventKeyMenuSettingsStatusLine(rune rune) {
+	chosen := a.menu.settingsStatusLine
+	segment := statusLineOptions(chosen)[a.menu.settingsStatusLineCurr]
+	i := slices.Index(chosen, segment)
+
+	switch rune {
+	case 'x':
+		if i >= 0 {
+			chosen = slices.Delete(chosen, i, i+1)
+		} else {
+			chosen = append(chosen, segment)
+		}
+	case 'H':
+		if i > 0 {
+			chosen[i-1], chosen[i] = chosen[i], chosen[i-1]
+		}
+	case 'L':
+		if i >= 0 && i < len(chosen)-1 {
+			chosen[i+1], chosen[i] = chosen[i], chosen[i+1]
+		}
+	}
+
+	// the cursor stays on the segment
+	a.menu.settingsStatusLine = chosen
+	a.menu.settingsStatusLineCurr = slices.Index(statusLineOptions(chosen), segment)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Everything termines persists. The bbolt implementation is opened once in
+// main and held on app for the whole run, the memory one keeps everything in
+// maps and is meant for tests.
+type storage interface {
+	saveGame(gInfo gameInfo, gData gameData) error
+	loadGameInfoAndData(id uuid.UUID) (gameInfo, gameData, error)
+	// ids of every game matching q, in the order q sorts by
+	queryGameIds(q gameQuery) ([]uuid.UUID, error)
+	// infos in the same order as ids, unknown ids are skipped
+	loadGameInfos(ids []uuid.UUID) ([]gameInfo, error)
+	// replaces the info of a saved game, its data stays the same
+	updateGameInfo(gInfo gameInfo) error
+	// every tag of saved games, sorted
+	loadTags() ([]string, error)
+	// moves all of ids to the trash at once, or none of them on error
+	trashGames(ids []uuid.UUID, deletedAt time.Time) error
+	// trashed games, the most recently deleted first
+	loadTrash() ([]trashedGame, error)
+	restoreGames(ids []uuid.UUID) error
+	// deletes trashed games for good
+	purgeGames(ids []uuid.UUID) error
+	// purges every game trashed before t
+	purgeTrash(before time.Time) error
+
+	initSettings() error
+	getSettings() (settings, error)
+	updateSettings(sett settings) error
+
+	// snapshot of the database in the backups directory, returns its path
+	backup() (string, error)
+	// replaces the database with the backup at path after validating it,
+	// returns the path of the backup taken of the replaced database
+	restore(path string) (string, error)
+	// rewrites the database without free pages, returns sizes in bytes before and after
+	compact() (int64, int64, error)
+	// adds the saved games of the database at path that aren't here yet
+	importGames(path string) (importReport, error)
+
+	// upgrades persisted records written by older versions of termines
+	migrate() error
+	close() error
+}
+
+func defaultSettings() settings {
+	return settings{
+		Theme:              "DEFAULT",
+		MaxScrolloff:       2,
+		TrashRetentionDays: 30,
+		ColorMode:          "AUTO",
+		Glyphs:             "ASCII",
+		CellWidth:          1,
+		Grid:               "OFF",
+		StatusLine:         []string{"RESULT", "MINES_LEFT", "TIMER", "SIZE", "CURSOR", "STEP", "PROGRESS", "DATE"},
+		TimerDecimals:      1,
+	}
+}
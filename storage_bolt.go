@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// what storage calls return once the database couldn't be reopened after
+// replacing its file
+var errDatabaseClosed = errors.New("database is closed")
+
+type boltStorage struct {
+	// read locked by every use of db through view and update, restore and
+	// compact lock it to close and reopen db, nil when reopening failed
+	mu sync.RWMutex
+	db *bolt.DB
+	// absolute, restore and compact replace the file
+	path string
+}
+
+func openBoltStorage(path string) (*boltStorage, error) {
+	// bbolt locks the file for the whole time it is open, so a second
+	// instance would otherwise wait forever
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("%s is used by another termines instance", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltStorage{db: db, path: path}, nil
+}
+
+func (s *boltStorage) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return nil
+	}
+
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
+
+func (s *boltStorage) view(fn func(tx *bolt.Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.db == nil {
+		return errDatabaseClosed
+	}
+
+	return s.db.View(fn)
+}
+
+func (s *boltStorage) update(fn func(tx *bolt.Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.db == nil {
+		return errDatabaseClosed
+	}
+
+	return s.db.Update(fn)
+}
+
+func (s *boltStorage) saveGame(gInfo gameInfo, gData gameData) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucketGameInfo, err := tx.CreateBucketIfNotExists([]byte("GameInfo"))
+		if err != nil {
+			return err
+		}
+
+		if old := bucketGameInfo.Get([]byte(gInfo.Id.String())); old != nil {
+			oldInfo, err := fromRecord[gameInfo](old)
+			if err != nil {
+				return err
+			}
+
+			err = deleteGameIndexes(tx, oldInfo)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = putGameIndexes(tx, gInfo)
+		if err != nil {
+			return err
+		}
+
+		gobGameInfo, err := toRecord(gInfo)
+		if err != nil {
+			return err
+		}
+
+		err = bucketGameInfo.Put([]byte(gInfo.Id.String()), gobGameInfo)
+		if err != nil {
+			return err
+		}
+
+		bucketGameData, err := tx.CreateBucketIfNotExists([]byte("GameData"))
+		if err != nil {
+			return err
+		}
+
+		gobGameData, err := toRecord(gData)
+		if err != nil {
+			return err
+		}
+
+		return bucketGameData.Put([]byte(gData.Id.String()), gobGameData)
+	})
+}
+
+func (s *boltStorage) loadGameInfoAndData(id uuid.UUID) (gameInfo, gameData, error) {
+	var gInfo gameInfo
+	var gData gameData
+	err := s.view(func(tx *bolt.Tx) error {
+		bucketGameInfo := tx.Bucket([]byte("GameInfo"))
+		if bucketGameInfo == nil {
+			return fmt.Errorf("bucket doesn't exist")
+		}
+
+		gobGInfo := bucketGameInfo.Get([]byte(id.String()))
+		if gobGInfo == nil {
+			return fmt.Errorf("key doesn't exist")
+		}
+
+		var err error
+		gInfo, err = fromRecord[gameInfo](gobGInfo)
+		if err != nil {
+			return err
+		}
+
+		bucketGameData := tx.Bucket([]byte("GameData"))
+		if bucketGameData == nil {
+			return fmt.Errorf("bucket doesn't exist")
+		}
+
+		gobGData := bucketGameData.Get([]byte(id.String()))
+		if gobGData == nil {
+			return fmt.Errorf("key doesn't exist")
+		}
+
+		gData, err = fromRecord[gameData](gobGData)
+		return err
+	})
+	return gInfo, gData, err
+}
+
+func (s *boltStorage) queryGameIds(q gameQuery) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		ids, err = queryGameIndexes(tx, q)
+		return err
+	})
+	return ids, err
+}
+
+func (s *boltStorage) loadGameInfos(ids []uuid.UUID) ([]gameInfo, error) {
+	var infos []gameInfo
+	err := s.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte("GameInfo"))
+		if bucket == nil {
+			return nil
+		}
+
+		for _, id := range ids {
+			v := bucket.Get([]byte(id.String()))
+			if v == nil {
+				continue
+			}
+
+			info, err := fromRecord[gameInfo](v)
+			if err != nil {
+				return err
+			}
+
+			infos = append(infos, info)
+		}
+		return nil
+	})
+	return infos, err
+}
+
+// only games that are saved and not trashed can be updated
+func (s *boltStorage) updateGameInfo(gInfo gameInfo) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucketGameInfo := tx.Bucket([]byte("GameInfo"))
+		if bucketGameInfo == nil {
+			return fmt.Errorf("bucket doesn't exist")
+		}
+
+		old := bucketGameInfo.Get([]byte(gInfo.Id.String()))
+		if old == nil {
+			return fmt.Errorf("key doesn't exist")
+		}
+
+		oldInfo, err := fromRecord[gameInfo](old)
+		if err != nil {
+			return err
+		}
+
+		err = deleteGameIndexes(tx, oldInfo)
+		if err != nil {
+			return err
+		}
+
+		err = putGameIndexes(tx, gInfo)
+		if err != nil {
+			return err
+		}
+
+		gobGameInfo, err := toRecord(gInfo)
+		if err != nil {
+			return err
+		}
+
+		return bucketGameInfo.Put([]byte(gInfo.Id.String()), gobGameInfo)
+	})
+}
+
+func (s *boltStorage) loadTags() ([]string, error) {
+	var tags []string
+	err := s.view(func(tx *bolt.Tx) error {
+		tags = loadIndexTags(tx)
+		return nil
+	})
+	return tags, err
+}
+
+func (s *boltStorage) initSettings() error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucketSettings, err := tx.CreateBucketIfNotExists([]byte("Settings"))
+		if err != nil {
+			return err
+		}
+
+		value := bucketSettings.Get([]byte("ALL"))
+		if value != nil {
+			return nil
+		}
+
+		gobSettings, err := toRecord(defaultSettings())
+		if err != nil {
+			return err
+		}
+
+		return bucketSettings.Put([]byte("ALL"), gobSettings)
+	})
+}
+
+func (s *boltStorage) getSettings() (settings, error) {
+	var sett settings
+	err := s.view(func(tx *bolt.Tx) error {
+		bucketSettings := tx.Bucket([]byte("Settings"))
+		if bucketSettings == nil {
+			return fmt.Errorf("bucket doesn't exist")
+		}
+
+		gobSettings := bucketSettings.Get([]byte("ALL"))
+		if gobSettings == nil {
+			return fmt.Errorf("key doesn't exist")
+		}
+
+		var err error
+		sett, err = fromRecord[settings](gobSettings)
+		return err
+	})
+	return sett, err
+}
+
+func (s *boltStorage) updateSettings(sett settings) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucketSettings, err := tx.CreateBucketIfNotExists([]byte("Settings"))
+		if err != nil {
+			return err
+		}
+
+		gobSettings, err := toRecord(sett)
+		if err != nil {
+			return err
+		}
+
+		return bucketSettings.Put([]byte("ALL"), gobSettings)
+	})
+}
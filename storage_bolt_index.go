@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Secondary indexes of GameInfo. Keys start with the indexed fields in an
+// order preserving encoding and end with the game id, values are an index
+// summary, so queries are answered by range scans without decoding records.
+// IndexTag has a key for every tag of a game.
+var gameIndexBuckets = []string{"IndexCreatedAt", "IndexConfig", "IndexResult", "IndexDuration", "IndexTag"}
+
+const INDEX_SUMMARY_LEN int = 8 + 1 + 8 + 4 + 4 + 4 + 1
+
+// bits of the last summary byte
+const INDEX_FLAG_STARRED byte = 1
+
+type indexKey struct {
+	bucket string
+	key    []byte
+}
+
+func indexKeys(info gameInfo) []indexKey {
+	id := info.Id[:]
+	createdAt := binary.BigEndian.AppendUint64(nil, uint64(info.CreatedAt.UnixNano()))
+	duration := binary.BigEndian.AppendUint64(nil, uint64(info.GameDuration))
+	config := indexConfigPrefix(info.FieldWidth, info.FieldHeight, info.MineCount)
+
+	keys := []indexKey{
+		{bucket: "IndexCreatedAt", key: concatBytes(createdAt, id)},
+		{bucket: "IndexConfig", key: concatBytes(config, createdAt, id)},
+		{bucket: "IndexResult", key: concatBytes([]byte{byte(resultRank(info.Result))}, duration, id)},
+		{bucket: "IndexDuration", key: concatBytes(duration, id)},
+	}
+	for _, tag := range info.Tags {
+		keys = append(keys, indexKey{bucket: "IndexTag", key: concatBytes(indexTagPrefix(tag), createdAt, id)})
+	}
+
+	return keys
+}
+
+// tags can't contain 0, so no tag's keys are inside the range of another tag
+func indexTagPrefix(tag string) []byte {
+	return append([]byte(tag), 0)
+}
+
+func indexConfigPrefix(width, height, mineCount int) []byte {
+	prefix := binary.BigEndian.AppendUint32(nil, uint32(width))
+	prefix = binary.BigEndian.AppendUint32(prefix, uint32(height))
+	return binary.BigEndian.AppendUint32(prefix, uint32(mineCount))
+}
+
+func concatBytes(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+var resultsByRank = []string{"WON", "LOST", "NONE"}
+
+func encodeIndexSummary(info gameInfo) []byte {
+	summary := binary.BigEndian.AppendUint64(nil, uint64(info.CreatedAt.UnixNano()))
+	summary = append(summary, byte(resultRank(info.Result)))
+	summary = binary.BigEndian.AppendUint64(summary, uint64(info.GameDuration))
+	summary = binary.BigEndian.AppendUint32(summary, uint32(info.FieldWidth))
+	summary = binary.BigEndian.AppendUint32(summary, uint32(info.FieldHeight))
+	summary = binary.BigEndian.AppendUint32(summary, uint32(info.MineCount))
+
+	var flags byte
+	if info.Starred {
+		flags |= INDEX_FLAG_STARRED
+	}
+	return append(summary, flags)
+}
+
+// the game id is always the last 16 bytes of an index key
+func decodeIndexSummary(key, summary []byte) (gameInfo, error) {
+	if len(summary) != INDEX_SUMMARY_LEN || len(key) < 16 {
+		return gameInfo{}, fmt.Errorf("invalid index entry")
+	}
+
+	id, err := uuid.FromBytes(key[len(key)-16:])
+	if err != nil {
+		return gameInfo{}, err
+	}
+
+	rank := int(summary[8])
+	if rank >= len(resultsByRank) {
+		return gameInfo{}, fmt.Errorf("invalid index entry")
+	}
+
+	return gameInfo{
+		Id:           id,
+		CreatedAt:    time.Unix(0, int64(binary.BigEndian.Uint64(summary[0:8]))),
+		Result:       resultsByRank[rank],
+		GameDuration: time.Duration(binary.BigEndian.Uint64(summary[9:17])),
+		FieldWidth:   int(binary.BigEndian.Uint32(summary[17:21])),
+		FieldHeight:  int(binary.BigEndian.Uint32(summary[21:25])),
+		MineCount:    int(binary.BigEndian.Uint32(summary[25:29])),
+		Starred:      summary[29]&INDEX_FLAG_STARRED != 0,
+	}, nil
+}
+
+func putGameIndexes(tx *bolt.Tx, info gameInfo) error {
+	summary := encodeIndexSummary(info)
+	for _, k := range indexKeys(info) {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(k.bucket))
+		if err != nil {
+			return err
+		}
+
+		err = bucket.Put(k.key, summary)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteGameIndexes(tx *bolt.Tx, info gameInfo) error {
+	for _, k := range indexKeys(info) {
+		bucket := tx.Bucket([]byte(k.bucket))
+		if bucket == nil {
+			continue
+		}
+
+		err := bucket.Delete(k.key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Picks the index whose order or range fits the query best, the remaining
+// conditions are checked on the summaries.
+func queryGameIndexes(tx *bolt.Tx, q gameQuery) ([]uuid.UUID, error) {
+	var infos []gameInfo
+	collect := func(k, v []byte) error {
+		info, err := decodeIndexSummary(k, v)
+		if err != nil {
+			return err
+		}
+
+		if q.matches(info) {
+			infos = append(infos, info)
+		}
+		return nil
+	}
+
+	createdFrom, createdTo := indexTimeRange(q.CreatedFrom, q.CreatedTo)
+	durationFrom, durationTo := indexDurationRange(q.DurationMin, q.DurationMax)
+	reverse := q.SortBy == "LATEST" || q.SortBy == "WORST"
+
+	var err error
+	switch {
+	case q.Tag != "":
+		// games with a tag are few, the tag's range is sorted like the configuration one
+		prefix := indexTagPrefix(q.Tag)
+		err = scanIndex(tx, "IndexTag", concatBytes(prefix, createdFrom), rangeEnd(prefix, createdTo), false, collect)
+		if err == nil {
+			sortGameInfos(infos, q.SortBy)
+		}
+	case !q.FieldAll:
+		// only the matches of this configuration are sorted, in created at order already
+		prefix := indexConfigPrefix(q.FieldWidth, q.FieldHeight, q.FieldMineCount)
+		err = scanIndex(tx, "IndexConfig", concatBytes(prefix, createdFrom), rangeEnd(prefix, createdTo), false, collect)
+		if err == nil {
+			sortGameInfos(infos, q.SortBy)
+		}
+	case q.SortBy == "STARRED":
+		err = scanIndex(tx, "IndexCreatedAt", createdFrom, createdTo, true, collect)
+		if err == nil {
+			sortGameInfos(infos, q.SortBy)
+		}
+	case q.SortBy == "LATEST" || q.SortBy == "OLDEST":
+		if durationFrom != nil && createdFrom == nil && createdTo == nil {
+			err = scanIndex(tx, "IndexDuration", durationFrom, durationTo, false, collect)
+			if err == nil {
+				sortGameInfos(infos, q.SortBy)
+			}
+		} else {
+			err = scanIndex(tx, "IndexCreatedAt", createdFrom, createdTo, reverse, collect)
+		}
+	case q.SortBy == "BEST" || q.SortBy == "WORST":
+		if q.Result == "WON" || q.Result == "LOST" {
+			prefix := []byte{byte(resultRank(q.Result))}
+			err = scanIndex(tx, "IndexResult", concatBytes(prefix, durationFrom), rangeEnd(prefix, durationTo), reverse, collect)
+		} else {
+			err = scanIndex(tx, "IndexResult", nil, nil, reverse, collect)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(infos))
+	for i, info := range infos {
+		ids[i] = info.Id
+	}
+
+	return ids, nil
+}
+
+// every tag some game has, in byte order
+func loadIndexTags(tx *bolt.Tx) []string {
+	bucket := tx.Bucket([]byte("IndexTag"))
+	if bucket == nil {
+		return []string{}
+	}
+
+	tags := []string{}
+	c := bucket.Cursor()
+	for k, _ := c.First(); k != nil; {
+		end := bytes.IndexByte(k, 0)
+		if end < 0 {
+			break
+		}
+
+		tag := string(k[:end])
+		tags = append(tags, tag)
+
+		next := prefixEnd(indexTagPrefix(tag))
+		if next == nil {
+			break
+		}
+		k, _ = c.Seek(next)
+	}
+
+	return tags
+}
+
+// encoded bounds of the created at part of index keys, nil when unbounded
+func indexTimeRange(from, to time.Time) (start []byte, end []byte) {
+	if !from.IsZero() {
+		start = binary.BigEndian.AppendUint64(nil, uint64(from.UnixNano()))
+	}
+	if !to.IsZero() {
+		end = binary.BigEndian.AppendUint64(nil, uint64(to.UnixNano()))
+	}
+
+	return start, end
+}
+
+// max is inclusive, so the end is the key right after it
+func indexDurationRange(durationMin, durationMax time.Duration) (start []byte, end []byte) {
+	if durationMin > 0 {
+		start = binary.BigEndian.AppendUint64(nil, uint64(durationMin))
+	}
+	if durationMax > 0 {
+		end = binary.BigEndian.AppendUint64(nil, uint64(durationMax+1))
+	}
+
+	return start, end
+}
+
+// end of a range of keys starting with prefix, bound is nil when unbounded
+func rangeEnd(prefix, bound []byte) []byte {
+	if bound == nil {
+		return prefixEnd(prefix)
+	}
+
+	return concatBytes(prefix, bound)
+}
+
+// calls fn for every key in [start, end), in key order or reversed, nil end is unbounded
+func scanIndex(tx *bolt.Tx, name string, start, end []byte, reverse bool, fn func(k, v []byte) error) error {
+	bucket := tx.Bucket([]byte(name))
+	if bucket == nil {
+		return nil
+	}
+
+	c := bucket.Cursor()
+
+	var k, v []byte
+	if !reverse {
+		k, v = c.Seek(start)
+	} else if end == nil {
+		k, v = c.Last()
+	} else {
+		k, v = c.Seek(end)
+		if k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+	}
+
+	for k != nil && bytes.Compare(k, start) >= 0 && (end == nil || bytes.Compare(k, end) < 0) {
+		err := fn(k, v)
+		if err != nil {
+			return err
+		}
+
+		if !reverse {
+			k, v = c.Next()
+		} else {
+			k, v = c.Prev()
+		}
+	}
+
+	return nil
+}
+
+// smallest key greater than every key starting with prefix, nil if there is none
+func prefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	return nil
+}
+
+// schema versions 2 and 4
+func migrateBuildGameIndexes(tx *bolt.Tx) error {
+	for _, name := range gameIndexBuckets {
+		if tx.Bucket([]byte(name)) != nil {
+			err := tx.DeleteBucket([]byte(name))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	bucketGameInfo := tx.Bucket([]byte("GameInfo"))
+	if bucketGameInfo == nil {
+		return nil
+	}
+
+	var infos []gameInfo
+	err := bucketGameInfo.ForEach(func(_, v []byte) error {
+		info, err := fromRecord[gameInfo](v)
+		if err != nil {
+			return err
+		}
+
+		infos = append(infos, info)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		err := putGameIndexes(tx, info)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"math/rand/v2"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// A bbolt storage with count game infos of a few configurations, indexed
+// like saveGame does. Game data isn't needed by queries and is left out.
+func benchmarkGameStorage(b *testing.B, count int) *boltStorage {
+	b.Helper()
+
+	s, err := openBoltStorage(filepath.Join(b.TempDir(), "data.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { s.close() })
+
+	configs := [][3]int{{9, 9, 10}, {16, 16, 40}, {30, 16, 99}, {50, 50, 500}}
+	results := []string{"WON", "LOST"}
+	r := rand.New(rand.NewPCG(1, 1))
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucketGameInfo, err := tx.CreateBucketIfNotExists([]byte("GameInfo"))
+		if err != nil {
+			return err
+		}
+
+		for i := range count {
+			config := configs[r.IntN(len(configs))]
+			info := gameInfo{
+				Id:           uuid.New(),
+				GameDuration: time.Duration(r.IntN(600_000)) * time.Millisecond,
+				Result:       results[r.IntN(len(results))],
+				FieldWidth:   config[0],
+				FieldHeight:  config[1],
+				MineCount:    config[2],
+				CreatedAt:    createdAt.Add(time.Duration(i) * time.Minute),
+			}
+
+			err := putGameIndexes(tx, info)
+			if err != nil {
+				return err
+			}
+
+			value, err := toRecord(info)
+			if err != nil {
+				return err
+			}
+
+			err = bucketGameInfo.Put([]byte(info.Id.String()), value)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return s
+}
+
+// The first page of the best won 16x16 games, from the indexes next to
+// decoding and sorting every record like before they existed.
+func BenchmarkQueryGameIds(b *testing.B) {
+	s := benchmarkGameStorage(b, 100_000)
+	q := gameQuery{SortBy: "BEST", FieldWidth: 16, FieldHeight: 16, FieldMineCount: 40, Result: "WON"}
+
+	b.Run("index", func(b *testing.B) {
+		for b.Loop() {
+			ids, err := s.queryGameIds(q)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			_, err = s.loadGameInfos(ids[:min(SAVED_GAMES_PAGE_SIZE, len(ids))])
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("fullDecode", func(b *testing.B) {
+		for b.Loop() {
+			var infos []gameInfo
+			err := s.db.View(func(tx *bolt.Tx) error {
+				return tx.Bucket([]byte("GameInfo")).ForEach(func(_, v []byte) error {
+					info, err := fromRecord[gameInfo](v)
+					if err != nil {
+						return err
+					}
+
+					if q.matches(info) {
+						infos = append(infos, info)
+					}
+					return nil
+				})
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			sortGameInfos(infos, q.SortBy)
+			_ = infos[:min(SAVED_GAMES_PAGE_SIZE, len(infos))]
+		}
+	})
+}
+
+// the indexes answer like decoding every record and sorting them does
+func TestQueryGameIndexes(t *testing.T) {
+	s, err := openBoltStorage(filepath.Join(t.TempDir(), "data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.close()
+
+	r := rand.New(rand.NewPCG(1, 1))
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var infos []gameInfo
+	for i := range 200 {
+		width := 9 + r.IntN(2)*7
+		info := gameInfo{
+			Id: uuid.New(),
+			// no ties, which the indexes break by id both ways
+			GameDuration: time.Duration(r.IntN(100))*time.Second + time.Duration(i)*time.Millisecond,
+			Result:       []string{"WON", "LOST"}[r.IntN(2)],
+			FieldWidth:   width,
+			FieldHeight:  width,
+			MineCount:    10,
+			CreatedAt:    createdAt.Add(time.Duration(i) * time.Hour),
+			Starred:      r.IntN(5) == 0,
+		}
+		if r.IntN(4) == 0 {
+			info.Tags = []string{"tagged"}
+		}
+		infos = append(infos, info)
+
+		err := s.saveGame(info, gameData{Id: info.Id})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	queries := []gameQuery{
+		{SortBy: "LATEST", FieldAll: true},
+		{SortBy: "OLDEST", FieldAll: true, CreatedFrom: createdAt.Add(10 * time.Hour), CreatedTo: createdAt.Add(50 * time.Hour)},
+		{SortBy: "BEST", FieldAll: true, Result: "WON", DurationMin: 20 * time.Second, DurationMax: 60 * time.Second},
+		{SortBy: "WORST", FieldAll: true},
+		{SortBy: "LATEST", FieldAll: true, DurationMax: 30 * time.Second},
+		{SortBy: "STARRED", FieldAll: true},
+		{SortBy: "BEST", FieldWidth: 16, FieldHeight: 16, FieldMineCount: 10},
+		{SortBy: "WORST", FieldWidth: 9, FieldHeight: 9, FieldMineCount: 10, Result: "LOST"},
+		{SortBy: "OLDEST", FieldAll: true, Tag: "tagged", StarredOnly: true},
+	}
+	for _, q := range queries {
+		var want []uuid.UUID
+		for _, info := range sortedMatches(infos, q) {
+			want = append(want, info.Id)
+		}
+
+		ids, err := s.queryGameIds(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(ids, want) {
+			t.Errorf("%+v: indexes give %d games, decoding every record %d", q, len(ids), len(want))
+		}
+	}
+}
+
+// what a query returns from infos, ties in the order of their ids
+func sortedMatches(infos []gameInfo, q gameQuery) []gameInfo {
+	var matching []gameInfo
+	for _, info := range infos {
+		if q.matches(info) && (q.Tag == "" || slices.Contains(info.Tags, q.Tag)) {
+			matching = append(matching, info)
+		}
+	}
+
+	slices.SortFunc(matching, func(a, b gameInfo) int {
+		return slices.Compare(a.Id[:], b.Id[:])
+	})
+	sortGameInfos(matching, q.SortBy)
+
+	return matching
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Trashed games leave GameInfo and the indexes, so queries never see them.
+// Trash holds their info with the deletion time and TrashData their data
+// record unchanged, both keyed by id like GameInfo and GameData.
+
+func (s *boltStorage) trashGames(ids []uuid.UUID, deletedAt time.Time) error {
+	return s.update(func(tx *bolt.Tx) error {
+		buckets, err := createBuckets(tx, "GameInfo", "GameData", "Trash", "TrashData")
+		if err != nil {
+			return err
+		}
+		bucketGameInfo, bucketGameData, bucketTrash, bucketTrashData := buckets[0], buckets[1], buckets[2], buckets[3]
+
+		for _, id := range ids {
+			key := []byte(id.String())
+
+			value := bucketGameInfo.Get(key)
+			if value == nil {
+				continue
+			}
+
+			info, err := fromRecord[gameInfo](value)
+			if err != nil {
+				return err
+			}
+
+			err = deleteGameIndexes(tx, info)
+			if err != nil {
+				return err
+			}
+
+			trashed, err := toRecord(trashedGame{DeletedAt: deletedAt, Info: info})
+			if err != nil {
+				return err
+			}
+
+			err = bucketTrash.Put(key, trashed)
+			if err != nil {
+				return err
+			}
+
+			err = moveValue(bucketGameData, bucketTrashData, key)
+			if err != nil {
+				return err
+			}
+
+			err = bucketGameInfo.Delete(key)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *boltStorage) loadTrash() ([]trashedGame, error) {
+	var trash []trashedGame
+	err := s.view(func(tx *bolt.Tx) error {
+		bucketTrash := tx.Bucket([]byte("Trash"))
+		if bucketTrash == nil {
+			return nil
+		}
+
+		return bucketTrash.ForEach(func(_, v []byte) error {
+			trashed, err := fromRecord[trashedGame](v)
+			if err != nil {
+				return err
+			}
+
+			trash = append(trash, trashed)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortTrash(trash)
+	return trash, nil
+}
+
+func (s *boltStorage) restoreGames(ids []uuid.UUID) error {
+	return s.update(func(tx *bolt.Tx) error {
+		buckets, err := createBuckets(tx, "GameInfo", "GameData", "Trash", "TrashData")
+		if err != nil {
+			return err
+		}
+		bucketGameInfo, bucketGameData, bucketTrash, bucketTrashData := buckets[0], buckets[1], buckets[2], buckets[3]
+
+		for _, id := range ids {
+			key := []byte(id.String())
+
+			value := bucketTrash.Get(key)
+			if value == nil {
+				continue
+			}
+
+			trashed, err := fromRecord[trashedGame](value)
+			if err != nil {
+				return err
+			}
+
+			info, err := toRecord(trashed.Info)
+			if err != nil {
+				return err
+			}
+
+			err = bucketGameInfo.Put(key, info)
+			if err != nil {
+				return err
+			}
+
+			err = putGameIndexes(tx, trashed.Info)
+			if err != nil {
+				return err
+			}
+
+			err = moveValue(bucketTrashData, bucketGameData, key)
+			if err != nil {
+				return err
+			}
+
+			err = bucketTrash.Delete(key)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *boltStorage) purgeGames(ids []uuid.UUID) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return purgeTrashed(tx, ids)
+	})
+}
+
+func (s *boltStorage) purgeTrash(before time.Time) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucketTrash := tx.Bucket([]byte("Trash"))
+		if bucketTrash == nil {
+			return nil
+		}
+
+		var ids []uuid.UUID
+		err := bucketTrash.ForEach(func(_, v []byte) error {
+			trashed, err := fromRecord[trashedGame](v)
+			if err != nil {
+				return err
+			}
+
+			if trashed.DeletedAt.Before(before) {
+				ids = append(ids, trashed.Info.Id)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return purgeTrashed(tx, ids)
+	})
+}
+
+func purgeTrashed(tx *bolt.Tx, ids []uuid.UUID) error {
+	buckets, err := createBuckets(tx, "Trash", "TrashData")
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		for _, bucket := range buckets {
+			err := bucket.Delete([]byte(id.String()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func createBuckets(tx *bolt.Tx, names ...string) ([]*bolt.Bucket, error) {
+	buckets := make([]*bolt.Bucket, len(names))
+	for i, name := range names {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(name))
+		if err != nil {
+			return nil, err
+		}
+		buckets[i] = bucket
+	}
+
+	return buckets, nil
+}
+
+// a missing value is left missing
+func moveValue(from, to *bolt.Bucket, key []byte) error {
+	value := from.Get(key)
+	if value == nil {
+		return nil
+	}
+
+	err := to.Put(key, bytes.Clone(value))
+	if err != nil {
+		return err
+	}
+
+	return from.Delete(key)
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Records are kept gob encoded, same as on disk, so callers can't share
+// memory with what is stored.
+type memoryStorage struct {
+	mu        sync.Mutex
+	gameInfos map[uuid.UUID][]byte
+	gameDatas map[uuid.UUID][]byte
+	trash     map[uuid.UUID][]byte
+	trashData map[uuid.UUID][]byte
+	settings  []byte
+}
+
+func openMemoryStorage() *memoryStorage {
+	return &memoryStorage{
+		gameInfos: map[uuid.UUID][]byte{},
+		gameDatas: map[uuid.UUID][]byte{},
+		trash:     map[uuid.UUID][]byte{},
+		trashData: map[uuid.UUID][]byte{},
+	}
+}
+
+func (s *memoryStorage) close() error {
+	return nil
+}
+
+// everything in memory is written by this build
+func (s *memoryStorage) migrate() error {
+	return nil
+}
+
+func (s *memoryStorage) backup() (string, error) {
+	return "", fmt.Errorf("backups aren't supported in memory")
+}
+
+func (s *memoryStorage) restore(path string) (string, error) {
+	return "", fmt.Errorf("backups aren't supported in memory")
+}
+
+func (s *memoryStorage) compact() (int64, int64, error) {
+	return 0, 0, fmt.Errorf("compaction isn't supported in memory")
+}
+
+func (s *memoryStorage) importGames(path string) (importReport, error) {
+	return importReport{}, fmt.Errorf("importing isn't supported in memory")
+}
+
+func (s *memoryStorage) saveGame(gInfo gameInfo, gData gameData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	gobGameInfo, err := toRecord(gInfo)
+	if err != nil {
+		return err
+	}
+
+	gobGameData, err := toRecord(gData)
+	if err != nil {
+		return err
+	}
+
+	s.gameInfos[gInfo.Id] = gobGameInfo
+	s.gameDatas[gData.Id] = gobGameData
+	return nil
+}
+
+func (s *memoryStorage) loadGameInfoAndData(id uuid.UUID) (gameInfo, gameData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	gobGInfo, ok := s.gameInfos[id]
+	if !ok {
+		return gameInfo{}, gameData{}, fmt.Errorf("key doesn't exist")
+	}
+
+	gobGData, ok := s.gameDatas[id]
+	if !ok {
+		return gameInfo{}, gameData{}, fmt.Errorf("key doesn't exist")
+	}
+
+	gInfo, err := fromRecord[gameInfo](gobGInfo)
+	if err != nil {
+		return gameInfo{}, gameData{}, err
+	}
+
+	gData, err := fromRecord[gameData](gobGData)
+	return gInfo, gData, err
+}
+
+func (s *memoryStorage) queryGameIds(q gameQuery) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var infos []gameInfo
+	for _, v := range s.gameInfos {
+		info, err := fromRecord[gameInfo](v)
+		if err != nil {
+			return nil, err
+		}
+
+		if q.matches(info) && (q.Tag == "" || slices.Contains(info.Tags, q.Tag)) {
+			infos = append(infos, info)
+		}
+	}
+
+	// map order is random, ties are broken by id like in the indexes
+	slices.SortFunc(infos, func(a, b gameInfo) int {
+		return bytes.Compare(a.Id[:], b.Id[:])
+	})
+	sortGameInfos(infos, q.SortBy)
+
+	ids := make([]uuid.UUID, len(infos))
+	for i, info := range infos {
+		ids[i] = info.Id
+	}
+
+	return ids, nil
+}
+
+func (s *memoryStorage) loadGameInfos(ids []uuid.UUID) ([]gameInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var infos []gameInfo
+	for _, id := range ids {
+		v, ok := s.gameInfos[id]
+		if !ok {
+			continue
+		}
+
+		info, err := fromRecord[gameInfo](v)
+		if err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+func (s *memoryStorage) updateGameInfo(gInfo gameInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.gameInfos[gInfo.Id]; !ok {
+		return fmt.Errorf("key doesn't exist")
+	}
+
+	gobGameInfo, err := toRecord(gInfo)
+	if err != nil {
+		return err
+	}
+
+	s.gameInfos[gInfo.Id] = gobGameInfo
+	return nil
+}
+
+func (s *memoryStorage) loadTags() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tags := []string{}
+	for _, v := range s.gameInfos {
+		info, err := fromRecord[gameInfo](v)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tag := range info.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	slices.Sort(tags)
+	return tags, nil
+}
+
+func (s *memoryStorage) trashGames(ids []uuid.UUID, deletedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		v, ok := s.gameInfos[id]
+		if !ok {
+			continue
+		}
+
+		info, err := fromRecord[gameInfo](v)
+		if err != nil {
+			return err
+		}
+
+		trashed, err := toRecord(trashedGame{DeletedAt: deletedAt, Info: info})
+		if err != nil {
+			return err
+		}
+
+		s.trash[id] = trashed
+		if data, ok := s.gameDatas[id]; ok {
+			s.trashData[id] = data
+		}
+		delete(s.gameInfos, id)
+		delete(s.gameDatas, id)
+	}
+
+	return nil
+}
+
+func (s *memoryStorage) loadTrash() ([]trashedGame, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var trash []trashedGame
+	for _, v := range s.trash {
+		trashed, err := fromRecord[trashedGame](v)
+		if err != nil {
+			return nil, err
+		}
+
+		trash = append(trash, trashed)
+	}
+
+	sortTrash(trash)
+	return trash, nil
+}
+
+func (s *memoryStorage) restoreGames(ids []uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		v, ok := s.trash[id]
+		if !ok {
+			continue
+		}
+
+		trashed, err := fromRecord[trashedGame](v)
+		if err != nil {
+			return err
+		}
+
+		info, err := toRecord(trashed.Info)
+		if err != nil {
+			return err
+		}
+
+		s.gameInfos[id] = info
+		if data, ok := s.trashData[id]; ok {
+			s.gameDatas[id] = data
+		}
+		delete(s.trash, id)
+		delete(s.trashData, id)
+	}
+
+	return nil
+}
+
+func (s *memoryStorage) purgeGames(ids []uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		delete(s.trash, id)
+		delete(s.trashData, id)
+	}
+	return nil
+}
+
+func (s *memoryStorage) purgeTrash(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, v := range s.trash {
+		trashed, err := fromRecord[trashedGame](v)
+		if err != nil {
+			return err
+		}
+
+		if trashed.DeletedAt.Before(before) {
+			delete(s.trash, id)
+			delete(s.trashData, id)
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStorage) initSettings() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.settings != nil {
+		return nil
+	}
+
+	gobSettings, err := toRecord(defaultSettings())
+	if err != nil {
+		return err
+	}
+
+	s.settings = gobSettings
+	return nil
+}
+
+func (s *memoryStorage) getSettings() (settings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.settings == nil {
+		return settings{}, fmt.Errorf("key doesn't exist")
+	}
+
+	return fromRecord[settings](s.settings)
+}
+
+func (s *memoryStorage) updateSettings(sett settings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	gobSettings, err := toRecord(sett)
+	if err != nil {
+		return err
+	}
+
+	s.settings = gobSettings
+	return nil
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Both implementations ready to use as main leaves them, the bbolt one in a
+// temporary directory that backups go to as well.
+func testStorages(t *testing.T) map[string]storage {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	bolt, err := openBoltStorage(filepath.Join(t.TempDir(), "data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bolt.close() })
+
+	storages := map[string]storage{
+		"bolt":   bolt,
+		"memory": openMemoryStorage(),
+	}
+	for name, s := range storages {
+		err := s.migrate()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		err = s.initSettings()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	return storages
+}
+
+// a game played on a field created with seed, lasting as many seconds as seed
+func testGame(seed uint64, width, height, mineCount int, result string, createdAt time.Time) (gameInfo, gameData) {
+	id := uuid.New()
+	field := createField(width, height, mineCount, seed)
+	history := []historyStep{
+		{Kind: "MOVE", MoveX: 1, MoveY: 1, CurrGameDuration: time.Second},
+		{Kind: "OPEN", OpenResult: result, CurrGameDuration: time.Duration(seed) * time.Second},
+	}
+
+	gInfo := gameInfo{
+		Id:           id,
+		GameDuration: history[len(history)-1].CurrGameDuration,
+		Result:       result,
+		MineCount:    mineCount,
+		FieldWidth:   width,
+		FieldHeight:  height,
+		CreatedAt:    createdAt,
+		Seed:         seed,
+	}
+
+	return gInfo, gameData{Id: id, Field: field, History: history}
+}
+
+func TestStorageSaveAndLoad(t *testing.T) {
+	for name, s := range testStorages(t) {
+		t.Run(name, func(t *testing.T) {
+			createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+			gInfo, gData := testGame(1, 9, 9, 10, "WON", createdAt)
+			otherInfo, otherData := testGame(2, 16, 16, 40, "LOST", createdAt.Add(time.Hour))
+
+			err := s.saveGame(gInfo, gData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.saveGame(otherInfo, otherData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			loadedInfo, loadedData, err := s.loadGameInfoAndData(gInfo.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(loadedInfo, gInfo) {
+				t.Errorf("loaded info %+v, saved %+v", loadedInfo, gInfo)
+			}
+			if !reflect.DeepEqual(loadedData, gData) {
+				t.Errorf("loaded data %+v, saved %+v", loadedData, gData)
+			}
+
+			infos, err := s.loadGameInfos([]uuid.UUID{otherInfo.Id, uuid.New(), gInfo.Id})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(infos, []gameInfo{otherInfo, gInfo}) {
+				t.Errorf("loaded infos %+v, want the two saved ones in the order asked for", infos)
+			}
+
+			_, _, err = s.loadGameInfoAndData(uuid.New())
+			if err == nil {
+				t.Error("loading a game that was never saved succeeded")
+			}
+		})
+	}
+}
+
+func TestStorageQueryGameIds(t *testing.T) {
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var infos []gameInfo
+	var datas []gameData
+	for i, config := range []struct {
+		width, height, mineCount int
+		result                   string
+	}{
+		{9, 9, 10, "WON"},
+		{9, 9, 10, "LOST"},
+		{16, 16, 40, "WON"},
+		{9, 9, 10, "WON"},
+		{30, 16, 99, "LOST"},
+		{16, 16, 40, "WON"},
+	} {
+		gInfo, gData := testGame(uint64(i+1), config.width, config.height, config.mineCount, config.result, createdAt.Add(time.Duration(i)*time.Hour))
+		infos = append(infos, gInfo)
+		datas = append(datas, gData)
+	}
+
+	queries := map[string]gameQuery{
+		"all latest":   {SortBy: "LATEST", FieldAll: true},
+		"all best":     {SortBy: "BEST", FieldAll: true},
+		"config worst": {SortBy: "WORST", FieldWidth: 9, FieldHeight: 9, FieldMineCount: 10},
+		"won oldest":   {SortBy: "OLDEST", FieldAll: true, Result: "WON"},
+		"created":      {SortBy: "LATEST", FieldAll: true, CreatedFrom: createdAt.Add(time.Hour), CreatedTo: createdAt.Add(4 * time.Hour)},
+		"width":        {SortBy: "BEST", FieldAll: true, WidthMin: 16},
+	}
+
+	for name, s := range testStorages(t) {
+		t.Run(name, func(t *testing.T) {
+			for i := range infos {
+				err := s.saveGame(infos[i], datas[i])
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for queryName, q := range queries {
+				var want []uuid.UUID
+				for _, info := range sortedMatches(infos, q) {
+					want = append(want, info.Id)
+				}
+
+				ids, err := s.queryGameIds(q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Equal(ids, want) {
+					t.Errorf("%s: got %v, want %v", queryName, ids, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStorageUpdateGameInfo(t *testing.T) {
+	for name, s := range testStorages(t) {
+		t.Run(name, func(t *testing.T) {
+			createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+			gInfo, gData := testGame(1, 9, 9, 10, "WON", createdAt)
+			otherInfo, otherData := testGame(2, 9, 9, 10, "WON", createdAt.Add(time.Hour))
+			err := s.saveGame(gInfo, gData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.saveGame(otherInfo, otherData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gInfo.Starred = true
+			gInfo.Tags = []string{"speedrun", "blind"}
+			gInfo.Note = "no flags"
+			err = s.updateGameInfo(gInfo)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			loadedInfo, loadedData, err := s.loadGameInfoAndData(gInfo.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(loadedInfo, gInfo) || !reflect.DeepEqual(loadedData, gData) {
+				t.Errorf("loaded %+v, updated to %+v with the data kept", loadedInfo, gInfo)
+			}
+
+			tags, err := s.loadTags()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(tags, []string{"blind", "speedrun"}) {
+				t.Errorf("tags are %v", tags)
+			}
+
+			for _, q := range []gameQuery{
+				{SortBy: "LATEST", FieldAll: true, StarredOnly: true},
+				{SortBy: "LATEST", FieldAll: true, Tag: "blind"},
+			} {
+				ids, err := s.queryGameIds(q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Equal(ids, []uuid.UUID{gInfo.Id}) {
+					t.Errorf("%+v: got %v, want only the updated game", q, ids)
+				}
+			}
+
+			ids, err := s.queryGameIds(gameQuery{SortBy: "STARRED", FieldAll: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(ids, []uuid.UUID{gInfo.Id, otherInfo.Id}) {
+				t.Errorf("starred first: got %v", ids)
+			}
+
+			missing, _ := testGame(3, 9, 9, 10, "WON", createdAt)
+			err = s.updateGameInfo(missing)
+			if err == nil {
+				t.Error("updating a game that was never saved succeeded")
+			}
+		})
+	}
+}
+
+func TestStorageTrash(t *testing.T) {
+	for name, s := range testStorages(t) {
+		t.Run(name, func(t *testing.T) {
+			createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+			var infos []gameInfo
+			var datas []gameData
+			for i := range 3 {
+				gInfo, gData := testGame(uint64(i+1), 9, 9, 10, "WON", createdAt.Add(time.Duration(i)*time.Hour))
+				err := s.saveGame(gInfo, gData)
+				if err != nil {
+					t.Fatal(err)
+				}
+				infos = append(infos, gInfo)
+				datas = append(datas, gData)
+			}
+
+			deletedAt := createdAt.Add(24 * time.Hour)
+			err := s.trashGames([]uuid.UUID{infos[0].Id}, deletedAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.trashGames([]uuid.UUID{infos[1].Id}, deletedAt.Add(time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids, err := s.queryGameIds(gameQuery{SortBy: "LATEST", FieldAll: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(ids, []uuid.UUID{infos[2].Id}) {
+				t.Errorf("saved games after trashing two: %v", ids)
+			}
+
+			trash, err := s.loadTrash()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []trashedGame{
+				{DeletedAt: deletedAt.Add(time.Hour), Info: infos[1]},
+				{DeletedAt: deletedAt, Info: infos[0]},
+			}
+			if !reflect.DeepEqual(trash, want) {
+				t.Errorf("trash is %+v, want %+v", trash, want)
+			}
+
+			err = s.restoreGames([]uuid.UUID{infos[0].Id})
+			if err != nil {
+				t.Fatal(err)
+			}
+			loadedInfo, loadedData, err := s.loadGameInfoAndData(infos[0].Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(loadedInfo, infos[0]) || !reflect.DeepEqual(loadedData, datas[0]) {
+				t.Error("restored game differs from the saved one")
+			}
+
+			err = s.trashGames([]uuid.UUID{infos[2].Id}, deletedAt.Add(2*time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.purgeTrash(deletedAt.Add(90 * time.Minute))
+			if err != nil {
+				t.Fatal(err)
+			}
+			trash, err = s.loadTrash()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(trash) != 1 || trash[0].Info.Id != infos[2].Id {
+				t.Errorf("trash after purging the older game is %+v", trash)
+			}
+
+			err = s.purgeGames([]uuid.UUID{infos[2].Id})
+			if err != nil {
+				t.Fatal(err)
+			}
+			trash, err = s.loadTrash()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(trash) != 0 {
+				t.Errorf("trash after purging everything is %+v", trash)
+			}
+
+			for _, id := range []uuid.UUID{infos[1].Id, infos[2].Id} {
+				_, _, err = s.loadGameInfoAndData(id)
+				if err == nil {
+					t.Errorf("purged game %v still loads", id)
+				}
+			}
+		})
+	}
+}
+
+func TestStorageSettings(t *testing.T) {
+	for name, s := range testStorages(t) {
+		t.Run(name, func(t *testing.T) {
+			sett, err := s.getSettings()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sett, defaultSettings()) {
+				t.Errorf("settings after initSettings are %+v", sett)
+			}
+
+			sett.Theme = "DARK"
+			sett.StatusLine = []string{"TIMER", "3BV"}
+			sett.TimerDecimals = 2
+			err = s.updateSettings(sett)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// updated settings aren't reset
+			err = s.initSettings()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			loaded, err := s.getSettings()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(loaded, sett) {
+				t.Errorf("loaded settings %+v, updated to %+v", loaded, sett)
+			}
+		})
+	}
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// the built-in themes, in the order settings shows them
+var builtinThemeKeys = []string{"DEFAULT", "LIGHT", "DARK", "MONO", "DEUTERANOPIA", "PROTANOPIA", "TRITANOPIA"}
+
+//go:embed themes/*.json
+var builtinThemeFiles embed.FS
+
+// least width of the settings preview, wide cells can make it wider
+const PREVIEW_WIDTH int = 24
+
+// every kind of cell a theme styles, as Value and State
+var previewField = [][]fieldCell{
+	{{1, 1}, {2, 1}, {3, 1}, {4, 1}, {5, 1}, {6, 1}, {7, 1}, {8, 1}},
+	{{0, 1}, {1, 1}, {9, -1}, {1, 1}, {0, 0}, {9, 0}, {9, 1}, {0, 1}},
+}
+
+// Theme file as written in JSON. Colours are tcell colour names like "red",
+// palette numbers "0"-"255", "#rrggbb", or "reset" for the terminal's own
+// colour. Empty colours are inherited from the background and foreground.
+// Colours are fitted to what the terminal can show, Numbers16 replace the
+// fitted Numbers on terminals with less than 256 colours when set.
+type themeDef struct {
+	Name       string        `json:"name"`
+	Background string        `json:"background"`
+	Foreground string        `json:"foreground"`
+	Numbers    [9]string     `json:"numbers"`
+	Numbers16  [9]string     `json:"numbers16"`
+	Hidden     themeStyleDef `json:"hidden"`
+	Flag       themeStyleDef `json:"flag"`
+	Mine       themeStyleDef `json:"mine"`
+	Cursor     themeStyleDef `json:"cursor"`
+	Header     themeStyleDef `json:"header"`
+}
+
+type themeStyleDef struct {
+	Background string `json:"background"`
+	Foreground string `json:"foreground"`
+	Bold       bool   `json:"bold"`
+	Reverse    bool   `json:"reverse"`
+}
+
+type theme struct {
+	// file name without .json in upper case, what settings store
+	Key  string
+	Name string
+	// the file and the colours it was fitted to
+	source themeDef
+	colors int
+
+	def     tcell.Style
+	numbers [9]tcell.Style
+	hidden  tcell.Style
+	flag    tcell.Style
+	mine    tcell.Style
+	header  tcell.Style
+	// a cursor without colours of its own keeps the cell's colours
+	cursor       tcell.Style
+	cursorColors bool
+}
+
+func parseTheme(key string, data []byte, colors int) (theme, error) {
+	var def themeDef
+	err := json.Unmarshal(data, &def)
+	if err != nil {
+		return theme{}, err
+	}
+
+	return resolveTheme(key, def, colors)
+}
+
+func resolveTheme(key string, def themeDef, colors int) (theme, error) {
+	t := theme{Key: key, Name: def.Name, source: def, colors: colors}
+	if t.Name == "" {
+		t.Name = key
+	}
+
+	// there is nothing to inherit from yet, so empty is the terminal's own colour
+	t.def = tcell.StyleDefault.Background(tcell.ColorReset).Foreground(tcell.ColorReset)
+	var err error
+	t.def, err = t.parseStyle(themeStyleDef{Background: def.Background, Foreground: def.Foreground})
+	if err != nil {
+		return theme{}, err
+	}
+
+	for i, number := range def.Numbers {
+		if colors < 256 && def.Numbers16[i] != "" {
+			number = def.Numbers16[i]
+		}
+
+		t.numbers[i], err = t.parseStyle(themeStyleDef{Foreground: number})
+		if err != nil {
+			return theme{}, fmt.Errorf("number %d: %w", i, err)
+		}
+	}
+
+	styles := []struct {
+		name  string
+		def   themeStyleDef
+		style *tcell.Style
+	}{
+		{"hidden", def.Hidden, &t.hidden},
+		{"flag", def.Flag, &t.flag},
+		{"mine", def.Mine, &t.mine},
+		{"cursor", def.Cursor, &t.cursor},
+		{"header", def.Header, &t.header},
+	}
+	for _, s := range styles {
+		*s.style, err = t.parseStyle(s.def)
+		if err != nil {
+			return theme{}, fmt.Errorf("%s: %w", s.name, err)
+		}
+	}
+	t.cursorColors = def.Cursor.Background != "" || def.Cursor.Foreground != ""
+
+	return t, nil
+}
+
+// on top of the theme's default style
+func (t theme) parseStyle(def themeStyleDef) (tcell.Style, error) {
+	style := t.def
+
+	if def.Background != "" {
+		bg, err := parseThemeColor(def.Background)
+		if err != nil {
+			return style, err
+		}
+		bg, _ = fitColor(bg, t.colors)
+		style = style.Background(bg)
+	}
+
+	bold := def.Bold
+	if def.Foreground != "" {
+		fg, err := parseThemeColor(def.Foreground)
+		if err != nil {
+			return style, err
+		}
+		fg, bright := fitColor(fg, t.colors)
+		style = style.Foreground(fg)
+		bold = bold || bright
+	}
+
+	return style.Bold(bold).Reverse(def.Reverse), nil
+}
+
+func parseThemeColor(str string) (tcell.Color, error) {
+	str = strings.ToLower(strings.TrimSpace(str))
+
+	switch {
+	case str == "":
+		return tcell.ColorDefault, nil
+	case str == "reset":
+		return tcell.ColorReset, nil
+	case strings.HasPrefix(str, "#"):
+		if len(str) == 7 {
+			if v, err := strconv.ParseInt(str[1:], 16, 32); err == nil {
+				return tcell.NewHexColor(int32(v)), nil
+			}
+		}
+	default:
+		if n, err := strconv.Atoi(str); err == nil && n >= 0 && n <= 255 {
+			return tcell.PaletteColor(n), nil
+		}
+		if c, ok := tcell.ColorNames[str]; ok {
+			return c, nil
+		}
+	}
+
+	return tcell.ColorDefault, fmt.Errorf("unknown colour %q", str)
+}
+
+func (t theme) cellStyle(cell fieldCell) tcell.Style {
+	switch cell.State {
+	case CELL_STATE_HIDDEN:
+		return t.hidden
+	case CELL_STATE_FLAG:
+		return t.flag
+	}
+
+	if cell.Value == CELL_VALUE_MINE {
+		return t.mine
+	}
+
+	return t.numbers[cell.Value]
+}
+
+// Distinct Cells adds attributes to the theme's colours, so numbers that
+// only differ by colour in a theme still look different at a glance
+func distinctCellStyle(cell fieldCell, style tcell.Style) tcell.Style {
+	switch cell.State {
+	case CELL_STATE_HIDDEN:
+		return style
+	case CELL_STATE_FLAG:
+		return style.Bold(true)
+	}
+
+	switch cell.Value {
+	case 0:
+		return style.Dim(true)
+	case 2:
+		return style.Underline(true)
+	case 3:
+		return style.Bold(true)
+	case 4:
+		return style.Bold(true).Underline(true)
+	case 5:
+		return style.Italic(true)
+	case 6:
+		return style.Italic(true).Underline(true)
+	case 7:
+		return style.Italic(true).Bold(true)
+	case 8:
+		return style.Italic(true).Bold(true).Underline(true)
+	case CELL_VALUE_MINE:
+		return style.Bold(true).Underline(true)
+	}
+
+	return style
+}
+
+func (t theme) cursorStyle(cellStyle tcell.Style) tcell.Style {
+	if t.cursorColors {
+		return t.cursor
+	}
+
+	_, _, attrs := t.cursor.Decompose()
+	return cellStyle.Bold(attrs&tcell.AttrBold != 0).Reverse(attrs&tcell.AttrReverse != 0)
+}
+
+// Built-in themes followed by the ones in the themes directory of the config
+// dir, sorted by key. A user theme with a built-in's key replaces it. Broken
+// theme files are left out and returned joined in the error.
+func loadThemes(colors int) ([]theme, error) {
+	themes := []theme{}
+	for _, key := range builtinThemeKeys {
+		data, err := builtinThemeFiles.ReadFile("themes/" + strings.ToLower(key) + ".json")
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := parseTheme(key, data, colors)
+		if err != nil {
+			return nil, fmt.Errorf("built-in theme %s: %w", key, err)
+		}
+		themes = append(themes, t)
+	}
+
+	themesDir, err := getThemesDir()
+	if err != nil {
+		return themes, err
+	}
+
+	entries, err := os.ReadDir(themesDir)
+	if err != nil {
+		return themes, err
+	}
+
+	var errs []error
+	var userThemes []theme
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(themesDir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		key := strings.ToUpper(strings.TrimSuffix(entry.Name(), ".json"))
+		t, err := parseTheme(key, data, colors)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("theme %s: %w", entry.Name(), err))
+			continue
+		}
+
+		if i := slices.IndexFunc(themes, func(t theme) bool { return t.Key == key }); i >= 0 {
+			themes[i] = t
+		} else {
+			userThemes = append(userThemes, t)
+		}
+	}
+
+	slices.SortFunc(userThemes, func(a, b theme) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return append(themes, userThemes...), errors.Join(errs...)
+}
+
+// the first theme, DEFAULT, when key isn't one of themes
+func findTheme(themes []theme, key string) theme {
+	i := slices.IndexFunc(themes, func(t theme) bool { return t.Key == key })
+	return themes[max(i, 0)]
+}
+
+func getThemesDir() (string, error) {
+	terminesDir, err := getTerminesDir()
+	if err != nil {
+		return "", err
+	}
+
+	themesDir := filepath.Join(terminesDir, "themes")
+
+	err = os.MkdirAll(themesDir, 0o755)
+	if err != nil {
+		return "", err
+	}
+
+	return themesDir, nil
+}
+
+func (a *app) cycleTheme(key string, direction int) string {
+	keys := make([]string, len(a.themes))
+	for i, t := range a.themes {
+		keys[i] = t.Key
+	}
+
+	return cycleString(keys, key, direction)
+}
+
+// the theme chosen in settings applied to a small field, before it is saved
+func (a *app) drawSettingsThemePreview(y int) {
+	// in the colour mode chosen in settings too, the file was valid when loaded
+	t := findTheme(a.themes, a.menu.settingsThemeState)
+	t, _ = resolveTheme(t.Key, t.source, colorModeColors(a.menu.settingsColorMode, a.screen))
+	glyphs := findGlyphSet(a.menu.settingsGlyphs)
+	l := newFieldLayout(a.menu.settingsCellWidth, a.menu.settingsGrid, glyphs)
+
+	a.setContentString(0, y, a.defStyle, "Preview")
+
+	width := len(previewField[0])
+	height := len(previewField)
+	previewWidth := max(PREVIEW_WIDTH, l.cellOffset(0, width-1, l.cellWidth)+l.cellWidth)
+	previewHeight := l.cellOffset(0, height-1, 1) + 1
+
+	// the status line of a game being played
+	solved3BV, total3BV := field3BV(previewField)
+	var parts []string
+	for _, segment := range a.menu.settingsStatusLine {
+		parts = append(parts, statusSegmentStr(segment, statusInfo{
+			time:          42345 * time.Millisecond,
+			timerDecimals: a.menu.settingsTimerDecimals,
+			width:         width,
+			height:        height,
+			mineCount:     totalMineCount(previewField),
+			minesLeft:     minesLeft(previewField),
+			cursorX:       2,
+			cursorY:       1,
+			solved3BV:     solved3BV,
+			total3BV:      total3BV,
+		}))
+	}
+	screenWidth, _ := a.screen.Size()
+	header := fitStatusLine(parts, screenWidth)
+
+	for x := range max(previewWidth, runewidth.StringWidth(header)) {
+		a.screen.SetContent(x, y+1, ' ', nil, t.header)
+	}
+	a.setContentString(0, y+1, t.header, header)
+
+	for row := range previewHeight {
+		for x := range previewWidth {
+			a.screen.SetContent(x, y+2+row, ' ', nil, t.def)
+		}
+	}
+
+	for row, line := range previewField {
+		for x, cell := range line {
+			rune, style := glyphs.cellRune(cell), t.cellStyle(cell)
+			if a.menu.settingsDistinctCells {
+				style = distinctCellStyle(cell, style)
+			}
+			if row == 1 && x == 2 {
+				style = t.cursorStyle(style)
+			}
+			a.setCellContent(l.cellOffset(0, x, l.cellWidth), y+2+l.cellOffset(0, row, 1), l.cellWidth, rune, style)
+		}
+	}
+	a.drawFieldGrid(l, 0, y+2, 0, 0, width, height, t.def)
+}
+
+// header colours span the whole first row, not only its text
+func (a *app) drawHeaderBackground() {
+	screenWidth, _ := a.screen.Size()
+	for x := range screenWidth {
+		a.screen.SetContent(x, 0, ' ', nil, a.theme.header)
+	}
+}
+
+func (a *app) setTheme(key string) {
+	a.theme = findTheme(a.themes, key)
+	a.defStyle = a.theme.def
+	a.screen.SetStyle(a.defStyle)
+}
+
+// picks up theme files added while termines is running
+func (a *app) reloadThemes() {
+	themes, err := loadThemes(a.colors())
+	if len(themes) > 0 {
+		a.themes = themes
+	}
+	if err != nil {
+		a.log(err)
+	}
+}
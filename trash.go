@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/google/uuid"
+)
+
+type trashedGame struct {
+	DeletedAt time.Time
+	Info      gameInfo
+}
+
+func sortTrash(trash []trashedGame) {
+	slices.SortStableFunc(trash, func(a, b trashedGame) int {
+		if c := b.DeletedAt.Compare(a.DeletedAt); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.Info.Id[:], b.Info.Id[:])
+	})
+}
+
+func purgeExpiredTrash(store storage, sett settings) error {
+	if sett.TrashRetentionDays <= 0 {
+		return nil
+	}
+
+	return store.purgeTrash(time.Now().AddDate(0, 0, -sett.TrashRetentionDays))
+}
+
+// purges what expired while termines was running, then loads the rest
+func (a *app) reloadTrash() error {
+	a.wg.Add(1)
+	defer a.wg.Done()
+
+	err := purgeExpiredTrash(a.store, a.settings)
+	if err != nil {
+		return err
+	}
+
+	trash, err := a.store.loadTrash()
+	if err != nil {
+		return err
+	}
+
+	a.menu.trash = trash
+	a.alignTrash()
+	return nil
+}
+
+func (a *app) restoreGames(ids []uuid.UUID) error {
+	a.wg.Add(1)
+	defer a.wg.Done()
+
+	return a.store.restoreGames(ids)
+}
+
+func (a *app) purgeGames(ids []uuid.UUID) error {
+	a.wg.Add(1)
+	defer a.wg.Done()
+
+	return a.store.purgeGames(ids)
+}
+
+func (a *app) alignTrash() {
+	_, screenHeight := a.screen.Size()
+	listHeight := max(screenHeight-1, 1)
+
+	a.menu.trashCurr = max(min(a.menu.trashCurr, len(a.menu.trash)-1), 0)
+
+	if a.menu.trashCurr < a.menu.trashScreenOffset {
+		a.menu.trashScreenOffset = a.menu.trashCurr
+	}
+	if a.menu.trashCurr >= a.menu.trashScreenOffset+listHeight {
+		a.menu.trashScreenOffset = a.menu.trashCurr - listHeight + 1
+	}
+	a.menu.trashScreenOffset = max(min(a.menu.trashScreenOffset, len(a.menu.trash)-listHeight), 0)
+}
+
+func (a *app) drawMenuTrash() {
+	headerStr := "Trash " + strconv.Itoa(len(a.menu.trash)) + " games"
+	if a.settings.TrashRetentionDays > 0 {
+		headerStr += ", purged after " + strconv.Itoa(a.settings.TrashRetentionDays) + " days"
+	}
+	a.setContentString(0, 0, a.defStyle, headerStr)
+
+	_, screenHeight := a.screen.Size()
+	for i := range screenHeight - 1 {
+		idx := i + a.menu.trashScreenOffset
+		if idx >= len(a.menu.trash) {
+			break
+		}
+
+		trashed := a.menu.trash[idx]
+		str := strconv.Itoa(idx+1) + "." + savedGameRowStr(trashed.Info) + " deleted " + trashed.DeletedAt.Format("2006-01-02 15:04:05")
+
+		if idx == a.menu.trashCurr {
+			a.setContentString(0, i+1, a.defStyle.Reverse(true), str)
+		} else {
+			a.setContentString(0, i+1, a.defStyle, str)
+		}
+	}
+}
+
+func (a *app) eventKeyMenuTrash(key tcell.Key, rune rune) {
+	if rune == 'b' {
+		a.menu.menuState = "SELECT"
+		return
+	}
+
+	if len(a.menu.trash) == 0 {
+		return
+	}
+
+	if rune == 'r' {
+		err := a.restoreGames([]uuid.UUID{a.menu.trash[a.menu.trashCurr].Info.Id})
+		if err != nil {
+			a.log(err)
+			a.cancel()
+			return
+		}
+
+		err = a.reloadTrash()
+		if err != nil {
+			a.log(err)
+			a.cancel()
+			return
+		}
+	}
+
+	if rune == 'm' {
+		if time.Since(a.menu.trashLastMPress).Abs() < time.Second/2 &&
+			a.menu.trashLastMPressIndex == a.menu.trashCurr {
+			err := a.purgeGames([]uuid.UUID{a.menu.trash[a.menu.trashCurr].Info.Id})
+			if err != nil {
+				a.log(err)
+				a.cancel()
+				return
+			}
+
+			err = a.reloadTrash()
+			if err != nil {
+				a.log(err)
+				a.cancel()
+				return
+			}
+		} else {
+			a.menu.trashLastMPress = time.Now()
+			a.menu.trashLastMPressIndex = a.menu.trashCurr
+		}
+	}
+
+	if rune == 'j' || key == tcell.KeyDown {
+		if a.menu.trashCurr == len(a.menu.trash)-1 {
+			a.menu.trashCurr = 0
+		} else {
+			a.menu.trashCurr++
+		}
+		a.alignTrash()
+	}
+
+	if rune == 'k' || key == tcell.KeyUp {
+		if a.menu.trashCurr == 0 {
+			a.menu.trashCurr = len(a.menu.trash) - 1
+		} else {
+			a.menu.trashCurr--
+		}
+		a.alignTrash()
+	}
+}